@@ -240,6 +240,8 @@ func (net *BPNetwork) createWire(conn1, conn2 BPConnection) {
 type BPConnection struct {
 	ent         ConnectorEntity
 	isSecondary bool
+	// The network connected to.
+	net *BPNetwork
 }
 
 func (b BPConnection) getCircuitId() int {
@@ -938,12 +940,13 @@ type PowerEntity interface {
 
 type LampEntity struct {
 	BaseEntity
+	SingleConnectorBase
 	Name            Name[LampEntity]    `json:"name"`
 	ControlBehavior LampControlBehavior `json:"control_behavior"`
 }
 
 func (LampEntity) name() string {
-	return "lamp"
+	return "small-lamp"
 }
 
 type ArithmeticCombinatorEntity struct {
@@ -1043,6 +1046,9 @@ type Planner struct {
 	placed  int
 	ringIdx int
 	free    []Free2x1Location
+	// The empty constant combinators placed to relay networks, each of which
+	// can carry a red and a green one.
+	relays map[Position]*ConstantCombinatorEntity
 }
 
 type RoboportEntity struct {
@@ -1456,187 +1462,445 @@ func DoLargeBuild(o io.Writer) {
 	pl.Save(o)
 }
 
-type placerStackItem struct {
-	*stackItem
-	p         Position
-	d         Direction
-	idx       int
-	undoFuncs []func(item *placerStackItem)
-	attempted map[Entity]struct{}
-	sids      []int
-}
+// The reach of a wire, which is how far apart the members of a network can be
+// placed from the nearest other member.
+const wireReach = 10
+
+// How much a spot is penalised for each network with no member in reach,
+// which has to be relayed to it.  Any spot in reach is better.
+const relayPenalty = 1000
 
-func (p *placerStackItem) addUndo(fn func(item *placerStackItem)) {
-	p.undoFuncs = append(p.undoFuncs, fn)
+// How far apart the tiles kept free for relays are.
+const relaySpacing = 4
+
+// Places the entities one at a time, each at the free spot nearest the placed
+// members of the networks it's on.  Entities are placed in the order they're
+// reached through their networks, so the networks of each one mostly have a
+// member placed just before it.
+//
+// Nothing is moved once it's placed, so an entity can be on networks whose
+// members are too far apart for any spot to be in reach of all of them.  Those
+// networks are relayed to it through empty constant combinators (see relay).
+func (p *Planner) placeEntities(entities []Entity) {
+	for _, ent := range connectedOrder(entities) {
+		pos, dir := p.findSpot(ent)
+		p.place(ent, pos, dir)
+
+		if c, ok := ent.(ConnectorEntity); ok {
+			for _, conn := range entityConnections(c) {
+				if !p.connect(conn) {
+					p.relay(conn)
+				}
+			}
+		}
+	}
 }
 
-func (p *placerStackItem) undo() {
-	for i := len(p.undoFuncs) - 1; i >= 0; i-- {
-		p.undoFuncs[i](p)
+// The connections of e, one for each network it's on.
+func entityConnections(e ConnectorEntity) (conns []BPConnection) {
+	add := func(conn *EntityConnector, isSecondary bool) {
+		if conn.redNet != nil {
+			conns = append(conns, BPConnection{ent: e, isSecondary: isSecondary, net: conn.redNet})
+		}
+
+		if conn.greenNet != nil {
+			conns = append(conns, BPConnection{ent: e, isSecondary: isSecondary, net: conn.greenNet})
+		}
+	}
+
+	add(e.PrimaryConnector(), false)
+
+	if dce, ok := e.(DualConnectorEntity); ok {
+		add(dce.SecondaryConnector(), true)
 	}
 
-	p.undoFuncs = nil
+	return
 }
 
-func (p *placerStackItem) connect(e ConnectorEntity, net *BPNetwork, isSecondary bool) bool {
-	newConn := BPConnection{
-		ent:         e,
-		isSecondary: isSecondary,
+// The order to place the entities in: each is followed by the entity on the
+// most networks which have a member placed (the latest reached, if there are
+// several), so entities joining networks placed apart are placed as soon as
+// they can be, while those networks are still near each other, and networks
+// are finished before the area around their members fills up.
+func connectedOrder(entities []Entity) []Entity {
+	var (
+		order   = make([]Entity, 0, len(entities))
+		members = map[*BPNetwork][]Entity{}
+		started = map[*BPNetwork]bool{}
+		// The number of networks each entity is on which have a member placed,
+		// and the entities reached by how many, in the order they were.
+		reached = map[Entity]int{}
+		queues  [][]Entity
+		placed  = map[Entity]bool{}
+	)
+
+	for _, ent := range entities {
+		if c, ok := ent.(ConnectorEntity); ok {
+			for _, conn := range entityConnections(c) {
+				members[conn.net] = append(members[conn.net], ent)
+			}
+		}
 	}
 
-	net.connections = append(net.connections, newConn)
-	p.addUndo(func(*placerStackItem) {
-		net.connections = net.connections[:len(net.connections)-1]
-	})
+	next := func() Entity {
+		for i := len(queues) - 1; i > 0; i-- {
+			for len(queues[i]) > 0 {
+				ent := queues[i][len(queues[i])-1]
+				queues[i] = queues[i][:len(queues[i])-1]
 
-	if len(net.connections) == 1 {
-		return true
+				// Entities are queued again when they're reached by another
+				// network.
+				if !placed[ent] && reached[ent] == i {
+					return ent
+				}
+			}
+		}
+
+		for _, ent := range entities {
+			if !placed[ent] {
+				return ent
+			}
+		}
+
+		return nil
 	}
 
-	for _, conn := range net.connections[:len(net.connections)-1] {
-		if e.Pos().canConnect(conn.ent.Pos()) {
-			net.createWire(newConn, conn)
-			p.addUndo(func(*placerStackItem) {
-				newConn.connector().undoConnection(net)
-				conn.connector().undoConnection(net)
-			})
-			return true
+	for ent := next(); ent != nil; ent = next() {
+		placed[ent] = true
+		order = append(order, ent)
+
+		c, ok := ent.(ConnectorEntity)
+
+		if !ok {
+			continue
+		}
+
+		for _, conn := range entityConnections(c) {
+			if started[conn.net] {
+				continue
+			}
+
+			started[conn.net] = true
+
+			for _, other := range members[conn.net] {
+				if placed[other] {
+					continue
+				}
+
+				reached[other]++
+
+				for len(queues) <= reached[other] {
+					queues = append(queues, nil)
+				}
+
+				queues[reached[other]] = append(queues[reached[other]], other)
+			}
 		}
 	}
 
-	return false
+	return order
 }
 
-func (p *placerStackItem) makeConnections(e ConnectorEntity) bool {
-	conn := e.PrimaryConnector()
+// The free spot for ent nearest the placed members of its networks, preferring
+// spots in reach of a member of each.  Entities which aren't on any network
+// with a member placed go as near the roboport as there's room.
+func (p *Planner) findSpot(ent Entity) (pos Position, dir Direction) {
+	var (
+		placed [][]BPConnection
+		// Spots in reach of a member of each network are in reach of the
+		// smallest, so the area around its members is searched first.
+		reach *Bounds
+	)
 
-	if conn.redNet != nil {
-		if !p.connect(e, conn.redNet, false) {
-			return false
+	if c, isConn := ent.(ConnectorEntity); isConn {
+		var smallest []BPConnection
+
+		for _, conn := range entityConnections(c) {
+			if len(conn.net.connections) == 0 {
+				continue
+			}
+
+			placed = append(placed, conn.net.connections)
+
+			if smallest == nil || len(conn.net.connections) < len(smallest) {
+				smallest = conn.net.connections
+			}
 		}
-	}
 
-	if conn.greenNet != nil {
-		if !p.connect(e, conn.greenNet, false) {
-			return false
+		if smallest != nil {
+			reach = &Bounds{smallest[0].ent.Pos(), smallest[0].ent.Pos()}
+
+			for _, conn := range smallest[1:] {
+				at := conn.ent.Pos()
+				reach.BL.X, reach.BL.Y = math.Min(reach.BL.X, at.X), math.Min(reach.BL.Y, at.Y)
+				reach.TR.X, reach.TR.Y = math.Max(reach.TR.X, at.X), math.Max(reach.TR.Y, at.Y)
+			}
+
+			reach.BL = reach.BL.shift(-wireReach, -wireReach)
+			reach.TR = reach.TR.shift(wireReach, wireReach)
 		}
 	}
 
-	if dce, ok := e.(DualConnectorEntity); ok {
-		conn = dce.SecondaryConnector()
+	var (
+		best = math.Inf(1)
+		l, w = ent.getSize(DirectionNorth)
+	)
 
-		if conn.redNet != nil {
-			if !p.connect(e, conn.redNet, true) {
-				return false
+	try := func(at Position, d Direction) {
+		l, w := ent.getSize(d)
+		bounds := at.getBounds(l, w)
+
+		if !p.canPlace(bounds) || reservesRelay(bounds) {
+			return
+		}
+
+		score := 0.0
+
+		if len(placed) == 0 {
+			score = math.Hypot(at.X, at.Y)
+		}
+
+		for _, conns := range placed {
+			_, distance, inReach := nearestConnection(at, conns)
+
+			if !inReach {
+				distance += relayPenalty
 			}
+
+			score += distance
 		}
 
-		if conn.greenNet != nil {
-			if !p.connect(e, conn.greenNet, true) {
-				return false
+		if score < best {
+			best, pos, dir = score, at, d
+		}
+	}
+
+	for {
+		// The outermost ring is left free for wide entities to overlap.
+		radius := float64(len(p.rings) - 2)
+		area := Position{}.getBounds(radius, radius)
+
+		if reach != nil {
+			area.BL.X, area.BL.Y = math.Max(area.BL.X, reach.BL.X), math.Max(area.BL.Y, reach.BL.Y)
+			area.TR.X, area.TR.Y = math.Min(area.TR.X, reach.TR.X), math.Min(area.TR.Y, reach.TR.Y)
+		}
+
+		for x := math.Floor(area.BL.X); x <= area.TR.X; x++ {
+			for y := math.Floor(area.BL.Y); y <= area.TR.Y; y++ {
+				if l == .5 && w == .5 {
+					try(Position{x + .5, y + .5}, DirectionNorth)
+					continue
+				}
+
+				try(Position{x + .5, y}, DirectionNorth)
+				try(Position{x, y + .5}, DirectionEast)
 			}
 		}
+
+		switch {
+		case best < relayPenalty:
+			return
+		case reach != nil && area != *reach:
+			// There could be room in reach past the edge of the plot.
+			p.expand()
+		case !math.IsInf(best, 1):
+			return
+		case reach != nil:
+			// Every spot around the smallest network is taken, so ent has to
+			// be relayed to it.
+			reach = nil
+		default:
+			p.expand()
+		}
 	}
+}
 
-	return true
+// The member of conns nearest at, how far it is, and whether it's in reach.
+func nearestConnection(at Position, conns []BPConnection) (nearest BPConnection, distance float64, inReach bool) {
+	distance = math.Inf(1)
+
+	for _, conn := range conns {
+		x, y := at.distanceXY(conn.ent.Pos())
+		d := math.Hypot(x, y)
+
+		// Any member in reach is nearer than those which aren't.
+		switch canConnect := at.canConnect(conn.ent.Pos()); {
+		case canConnect && (!inReach || d < distance):
+			nearest, distance, inReach = conn, d, true
+		case !canConnect && !inReach && d < distance:
+			nearest, distance = conn, d
+		}
+	}
+
+	return
 }
 
-func (p *Planner) planSlow(entities []Entity) {
-	var (
-		entStackHead = stackItem{}
-		tail         = &entStackHead
-		placed       = map[Entity]struct{}{}
-		stack        = []placerStackItem{}
-		popped       = []placerStackItem{}
-	)
+// Whether a member of conns is in reach of the outermost rings of the plot,
+// which are left free.
+func nearEdge(plot Plot, conns []BPConnection) bool {
+	for _, conn := range conns {
+		if conn.ent.Pos().toRingIdx()+wireReach+2 >= len(plot.rings) {
+			return true
+		}
+	}
 
-	for _, ent := range entities {
-		tail = tail.add(ent)
+	return false
+}
+
+// Adds conn to its network, wiring it to the nearest member in reach.  Returns
+// false if there isn't one, in which case conn is left off the network.
+func (p *Planner) connect(conn BPConnection) bool {
+	to, _, inReach := nearestConnection(conn.ent.Pos(), conn.net.connections)
+
+	if len(conn.net.connections) > 0 && !inReach {
+		return false
 	}
 
-	var item placerStackItem
+	if len(conn.net.connections) > 0 {
+		conn.net.createWire(conn, to)
+	}
 
-	item.p, item.d = p.getNext2x1()
-	item.stackItem = &entStackHead
-	item.attempted = make(map[Entity]struct{})
+	conn.net.connections = append(conn.net.connections, conn)
 
-stackHead:
-	didPlace := false
-	item.stackItem.iterate(func(s *stackItem) (shouldBreak bool) {
+	return true
+}
 
-		item.undo()
-		item.sids = append(item.sids, s.id)
-		item.attempted[s.ent] = struct{}{}
+// Wires conn to its network through the shortest chain of empty constant
+// combinators on free spots, each in reach of the last.
+func (p *Planner) relay(conn BPConnection) {
+	at := conn.ent.Pos()
 
-		if _, wasPlaced := placed[item.ent]; wasPlaced {
+	for {
+		if to, _, ok := nearestConnection(at, conn.net.connections); ok {
+			conn.net.createWire(conn, to)
+			conn.net.connections = append(conn.net.connections, conn)
 			return
 		}
 
-		item.stackItem = s
+		if chain, from, ok := p.relayChain(at, conn.net.connections); ok {
+			for _, spot := range chain {
+				relay, ok := p.relays[spot]
 
-		placed[item.ent] = struct{}{}
-		item.stackItem.remove()
+				if !ok {
+					relay = &ConstantCombinatorEntity{ControlBehavior: ConstantControlBehavior{Filters: []ConstantFilter{}}}
+					p.place(relay, spot, DirectionNorth)
+					p.relays[spot] = relay
+				}
 
-		item.addUndo(func(item *placerStackItem) {
-			delete(placed, item.ent)
-			item.reinstate()
-		})
+				if conn.net.isGreen {
+					relay.PrimaryConnector().greenNet = conn.net
+				} else {
+					relay.PrimaryConnector().redNet = conn.net
+				}
 
-		l, w := item.ent.getSize(item.d)
-		var offX, offY float64
+				next := BPConnection{ent: relay, net: conn.net}
+				conn.net.createWire(next, from)
+				conn.net.connections = append(conn.net.connections, next)
+				from = next
+			}
 
-		if l == .5 && w == .5 {
-			if item.d.isVertical() {
-				offY = .5
-			} else {
-				offX = .5
+			continue
+		}
+
+		// There could be room past the edge of the plot.
+		if !nearEdge(p.Plot, append([]BPConnection{conn}, conn.net.connections...)) {
+			panic(fmt.Errorf("unable to lay out the blueprint: no room to relay a network to a %s", conn.ent.name()))
+		}
+
+		p.expand()
+	}
+}
+
+// The fewest spots from a member of conns to one in reach of at, each in reach
+// of the last, found breadth first.  Each spot is free or has a relay free for
+// the colour of conns.  from is the member the first is wired to.
+func (p *Planner) relayChain(at Position, conns []BPConnection) (chain []Position, from BPConnection, ok bool) {
+	var (
+		prev   = map[Position]Position{}
+		first  = map[Position]BPConnection{}
+		queue  []Position
+		radius = float64(len(p.rings) - 2)
+	)
+
+	free := func(pos Position) bool {
+		if _, seen := prev[pos]; seen || pos.toRingIdx() >= int(radius) {
+			return false
+		}
+
+		if relay, ok := p.relays[pos]; ok {
+			if conns[0].net.isGreen {
+				return relay.PrimaryConnector().greenNet == nil
 			}
+
+			return relay.PrimaryConnector().redNet == nil
 		}
 
-		p.place(item.ent, item.p.shift(offX, offY), item.d)
-		item.addUndo(func(item *placerStackItem) {
-			p.remove(item.ent)
-		})
+		return p.get(pos) == nil
+	}
 
-		if c, ok := item.ent.(ConnectorEntity); ok {
-			// if item.p.X == 5.5 && item.p.Y == -5 {
-			// 	fmt.Println(c.PrimaryConnector())
-			// }
-			if !item.makeConnections(c) {
-				return false
+	for _, conn := range conns {
+		origin := conn.ent.Pos()
+
+		for _, offset := range reachOffsets {
+			pos := Position{math.Floor(origin.X+offset.X) + .5, math.Floor(origin.Y+offset.Y) + .5}
+
+			if free(pos) && origin.canConnect(pos) {
+				prev[pos], first[pos] = pos, conn
+				queue = append(queue, pos)
 			}
 		}
+	}
 
-		stack = append(stack, item)
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
 
-		if len(popped) > 0 {
-			item = popped[len(popped)-1]
-			popped = popped[:len(popped)-1]
-		} else {
-			item = placerStackItem{}
-			item.p, item.d = p.getNext2x1()
+		if pos.canConnect(at) {
+			for ; prev[pos] != pos; pos = prev[pos] {
+				chain = append([]Position{pos}, chain...)
+			}
+
+			return append([]Position{pos}, chain...), first[pos], true
 		}
 
-		item.stackItem = &entStackHead
-		item.attempted = make(map[Entity]struct{})
-		shouldBreak = true
-		didPlace = true
-		return
-	})
-	// fmt.Println("placed", len(placed), len(entities), stack[len])
-	if didPlace && entStackHead.next != nil {
-		goto stackHead
-	}
+		for _, offset := range reachOffsets {
+			next := pos.shift(offset.X, offset.Y)
 
-	if entStackHead.next != nil || !didPlace {
-		item.undo()
-		popped = append(popped, item)
-		item = stack[len(stack)-1]
-		stack = stack[:len(stack)-1]
-		goto stackHead
+			if free(next) {
+				prev[next] = pos
+				queue = append(queue, next)
+			}
+		}
 	}
+
+	return nil, BPConnection{}, false
+}
+
+// Whether b holds a tile kept free for relays, one in every relaySpacing by
+// relaySpacing square, so networks can always be relayed past the entities
+// around them.
+func reservesRelay(b Bounds) (reserves bool) {
+	b.iterate(func(pos Position) bool {
+		reserves = math.Mod(math.Floor(pos.X), relaySpacing) == 0 && math.Mod(math.Floor(pos.Y), relaySpacing) == 0
+		return reserves
+	})
+
+	return
 }
 
+// The offsets of the tiles in reach of a tile.
+var reachOffsets = func() (offsets []Position) {
+	for x := -float64(wireReach); x <= wireReach; x++ {
+		for y := -float64(wireReach); y <= wireReach; y++ {
+			if (x != 0 || y != 0) && (Position{}).canConnect(Position{x, y}) {
+				offsets = append(offsets, Position{x, y})
+			}
+		}
+	}
+
+	return
+}()
+
 func (p *Planner) plan(networks []*Network) {
 	p.Plot = Plot{
 		rings: []Ring{
@@ -1649,6 +1913,7 @@ func (p *Planner) plan(networks []*Network) {
 	}
 
 	p.ringIdx = 2
+	p.relays = map[Position]*ConstantCombinatorEntity{}
 
 	p.place(&RoboportEntity{}, Position{}, DirectionDefault)
 
@@ -1690,5 +1955,5 @@ func (p *Planner) plan(networks []*Network) {
 		}
 	}
 
-	p.planSlow(ents)
+	p.placeEntities(ents)
 }
@@ -0,0 +1,111 @@
+package factorio
+
+import (
+	"fmt"
+	"main/generator"
+	"strings"
+)
+
+// Colour signals by the (lowercased) name of the enum member they stand for, ie
+// the members of std.tbd's Color.
+var colorSignals = map[string]*Signal{
+	"red":    SignalRed,
+	"green":  SignalGreen,
+	"blue":   SignalBlue,
+	"yellow": SignalYellow,
+	"pink":   SignalPink,
+	"purple": SignalPink,
+	"cyan":   SignalCyan,
+	"white":  SignalWhite,
+	"grey":   SignalGrey,
+	"gray":   SignalGrey,
+	"black":  SignalBlack,
+}
+
+// The signal for a member of an enum: the matching colour signal for colours,
+// otherwise the virtual signal with the same name (ie `A` is signal-A).
+func EnumSignal(enum *generator.Enum, value uint64) (*Signal, error) {
+	name := enum.MemberName(value)
+
+	if name == "" {
+		return nil, fmt.Errorf("%d is not a member of %s", value, enum.Name())
+	}
+
+	if sig, ok := colorSignals[strings.ToLower(name)]; ok {
+		return sig, nil
+	}
+
+	for _, sig := range signals {
+		if sig.Type == SignalTypeVirtual && sig.Name == "signal-"+name {
+			return sig, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no signal for %s.%s", enum.Name(), name)
+}
+
+// The control behaviour of a lamp which shows a member of a colour enum.
+func LampColor(enum *generator.Enum, value uint64) (LampControlBehavior, error) {
+	sig, err := EnumSignal(enum, value)
+
+	if err != nil {
+		return LampControlBehavior{}, err
+	}
+
+	return LampControlBehavior{
+		CircuitCondition: BooleanCondition{
+			FirstSignal: sig,
+			Comparator:  ComparatorGt,
+		},
+		UseColors: true,
+	}, nil
+}
+
+// Adds a decider for each member of enum which outputs the member's signal onto
+// out while in carries the member's value on sig, ie to drive a lamp with a
+// value only known at runtime.
+func (b *Builder) decodeEnum(enum *generator.Enum, in *Network, sig *Signal, out *Network) error {
+	for i := range enum.Members {
+		member, err := EnumSignal(enum, uint64(i))
+
+		if err != nil {
+			return err
+		}
+
+		(&Decider{
+			InputComponent: createInputs(sig, Constant(i)),
+			output:         member,
+			outputFixed:    true,
+			Operator:       ComparatorEq,
+		}).addInputs(in).addOutputs(out)
+	}
+
+	return nil
+}
+
+// Shows the value of c, which holds a member of enum, on a lamp for each member
+// which is lit while c holds it (in the member's colour, for a colour).  Enums
+// with a member which has no signal aren't shown.
+func (b *Builder) addEnumLamps(enum *generator.Enum, c *Cell) {
+	lamps := make([]*Lamp, len(enum.Members))
+
+	for i := range enum.Members {
+		behavior, err := LampColor(enum, uint64(i))
+
+		if err != nil {
+			return
+		}
+
+		lamps[i] = &Lamp{behavior: behavior}
+	}
+
+	lampNet := b.createNet(true)
+
+	if err := b.decodeEnum(enum, c.stoNet, SignalV, lampNet); err != nil {
+		panic(err)
+	}
+
+	for _, lamp := range lamps {
+		lampNet.connectInput(lamp)
+	}
+}
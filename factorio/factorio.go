@@ -281,7 +281,7 @@ func (d *Decider) Entity() ConnectorEntity {
 				Comparator:  d.Operator,
 			}
 		default:
-			panic(fmt.Sprintln("invalid secondary input for arithmetic: %s", inspector.Inspect(d)))
+			panic(fmt.Sprintf("invalid secondary input for decider: %s", inspector.Inspect(d)))
 		}
 
 		d.ent = &DeciderCombinatorEntity{
@@ -300,10 +300,26 @@ func (d *Decider) Entity() ConnectorEntity {
 	return d.ent
 }
 
+// A lamp, lit by the signals on the networks it's connected to.
+type Lamp struct {
+	InputComponent
+	behavior LampControlBehavior
+	ent      *LampEntity
+}
+
+func (l *Lamp) Entity() ConnectorEntity {
+	if l.ent == nil {
+		l.ent = &LampEntity{ControlBehavior: l.behavior}
+	}
+
+	return l.ent
+}
+
 type Builder struct {
 	networks []*Network
-	cells    map[*generator.Variable]*Cell
-	tickers  []Ticker
+	// The cells of variables and arguments.
+	cells   map[generator.Typed]*Cell
+	tickers []Ticker
 }
 
 type block struct {
@@ -325,6 +341,8 @@ type Cell struct {
 	id                 Constant
 	get, set, sto, tmp *Decider
 	net                *Network
+	// The network the value is stored on, as signal-V.
+	stoNet *Network
 }
 
 // func (b *Builder) nextSig() *Signal {
@@ -366,7 +384,11 @@ func (d *Decider) addOutputs(nets ...*Network) *Decider {
 	return d
 }
 
-func (b *Builder) createCell(net *Network, v *generator.Variable) (c *Cell) {
+func (b *Builder) createCell(net *Network, name string, typ generator.Type) (c *Cell) {
+	if typ.Kind() == generator.KindString {
+		panic(fmt.Errorf("unsupported variable '%s' of type string: signals can only hold numbers", name))
+	}
+
 	var (
 		setNet = b.createSubnet(net)
 		stoNet = b.createNet(false)
@@ -376,8 +398,9 @@ func (b *Builder) createCell(net *Network, v *generator.Variable) (c *Cell) {
 	id := net.nextCellID()
 
 	c = &Cell{
-		id:  id,
-		net: net,
+		id:     id,
+		net:    net,
+		stoNet: stoNet,
 
 		get: (&Decider{
 			InputComponent: InputComponent{
@@ -455,20 +478,23 @@ func (b *Builder) getTickerNetwork(tick int, isGreen, excludeMemOp bool) *Networ
 func (b *Builder) addControlTick(isGreen bool) (newtick int, end, next *Network) {
 	end = b.getTickerNetwork(len(b.tickers)-1, isGreen, false)
 
-	ticker := Ticker{
+	newtick = b.addJoinTick()
+	next = b.createNet(isGreen)
+	next.connectInput(b.tickers[newtick])
+	return
+}
+
+// Adds a ticker without connecting its input, for branches to join on.
+func (b *Builder) addJoinTick() int {
+	b.tickers = append(b.tickers, Ticker{
 		Arithmetic: &Arithmetic{
 			InputComponent: createInputs(SignalCheck, Constant(0)),
 			output:         SignalCheck,
 			Operator:       ArithmeticOperationOr,
 		},
 		isMemOp: true,
-	}
-	b.tickers = append(b.tickers, ticker)
-	newtick = len(b.tickers) - 1
-	// don't connect input
-	next = b.createNet(isGreen)
-	next.connectInput(ticker)
-	return
+	})
+	return len(b.tickers) - 1
 }
 
 func (b *Builder) getTicker(tick int, excludeMemOp bool) (t *Ticker) {
@@ -661,12 +687,87 @@ func (b *Builder) preserveUntil(net *Network, into *Network, sig *Signal, start,
 	into.connectOutput(ar)
 }
 
+// The comparator a decider uses for each comparison operator.
+var comparators = map[lexer.Token]Comparator{
+	lexer.EQL:         ComparatorEq,
+	lexer.NOT_EQL:     ComparatorNe,
+	lexer.LESS:        ComparatorLt,
+	lexer.LESS_EQL:    ComparatorLte,
+	lexer.GREATER:     ComparatorGt,
+	lexer.GREATER_EQL: ComparatorGte,
+}
+
+// Signals are 32 bit integers, so converting to a smaller integer type wraps
+// the value around and nothing else needs to change (enums are already
+// numbers).
+func (b *block) convert(v generator.Conversion, tick int, net *Network, sig *Signal) int {
+	from := v.Value.Type().Kind()
+
+	switch to := v.Type().Kind(); {
+	case from == generator.KindFloat32 || from == generator.KindFloat64:
+		panic(fmt.Errorf("unsupported conversion from %s", v.Value.Type().Name()))
+	case to == generator.KindFloat32 || to == generator.KindFloat64 || to == generator.KindString:
+		panic(fmt.Errorf("unsupported conversion to %s", v.Type().Name()))
+	case v.Value.Type().AssignableTo(v.Type()):
+		// Widening an integer doesn't change it.
+	case to == generator.KindInt8 || to == generator.KindInt16:
+		// Shifting right keeps the sign, so the top bits are copied from it.
+		shift := Constant(24)
+		if to == generator.KindInt16 {
+			shift = Constant(16)
+		}
+
+		right := &Arithmetic{}
+		rightNet := b.createSubnet(net)
+		rightNet.connectInput(right)
+		right.primaryInput = SignalW
+		right.secondaryInput = shift
+		right.output = sig
+		net.connectOutput(right)
+		right.Operator = ArithmeticOperationRightShift
+
+		left := &Arithmetic{}
+		leftNet := b.createSubnet(rightNet)
+		leftNet.connectInput(left)
+		left.primaryInput = SignalW
+		left.secondaryInput = shift
+		left.output = SignalW
+		rightNet.connectOutput(left)
+		left.Operator = ArithmeticOperationLeftShift
+
+		return b.extractValue(v.Value, tick, leftNet, SignalW) + 2
+	case to == generator.KindUint8 || to == generator.KindUint16:
+		mask := Constant(0xff)
+		if to == generator.KindUint16 {
+			mask = Constant(0xffff)
+		}
+
+		ar := &Arithmetic{}
+		subnet := b.createSubnet(net)
+		subnet.connectInput(ar)
+		ar.primaryInput = SignalW
+		ar.secondaryInput = mask
+		ar.output = sig
+		net.connectOutput(ar)
+		ar.Operator = ArithmeticOperationAnd
+
+		return b.extractValue(v.Value, tick, subnet, SignalW) + 1
+	}
+
+	return b.extractValue(v.Value, tick, net, sig)
+}
+
 func (b *block) extractValue(
 	typ generator.Typed,
 	tick int,
 	net *Network,
 	sig *Signal,
 ) int {
+	// Signals can only hold numbers, so strings (and operations on them) can't
+	// be built.
+	if typ.Type().Kind() == generator.KindString {
+		panic(fmt.Errorf("unsupported value of type string: signals can only hold numbers"))
+	}
 
 	switch v := typ.(type) {
 	case generator.UnaryOperation:
@@ -692,6 +793,17 @@ func (b *block) extractValue(
 			ar.Operator = ArithmeticOperationXor
 
 			return b.extractValue(v.Operand, tick, subnet, SignalT) + 1
+		case lexer.NOT:
+			// bools are 0 or 1.
+			subnet := b.createSubnet(net)
+			subnet.connectInput(ar)
+			ar.primaryInput = SignalN
+			ar.secondaryInput = Constant(1)
+			ar.output = sig
+			net.connectOutput(ar)
+			ar.Operator = ArithmeticOperationXor
+
+			return b.extractValue(v.Operand, tick, subnet, SignalN) + 1
 		}
 	case generator.BinaryOperation:
 		// sn := b.createSubnet(net)
@@ -750,19 +862,52 @@ func (b *block) extractValue(
 			net.connectOutput(ar)
 			sn.connectInput(ar)
 
+			return tick + 1
+		case lexer.BOOLEAN_AND, lexer.BOOLEAN_OR:
+			// bools are 0 or 1, so these are the bitwise operations.  Both sides
+			// are always evaluated.
+			var op ArithmeticOperation = ArithmeticOperationAnd
+			if v.Operator == lexer.BOOLEAN_OR {
+				op = ArithmeticOperationOr
+			}
+
+			ar := &Arithmetic{
+				InputComponent: ic,
+				output:         sig,
+				Operator:       op,
+			}
+			net.connectOutput(ar)
+			sn.connectInput(ar)
+
+			return tick + 1
+		}
+
+		if cmp, ok := comparators[v.Operator]; ok {
+			// Outputs 1 if the comparison holds, otherwise nothing (0).
+			d := &Decider{
+				InputComponent: ic,
+				output:         sig,
+				outputFixed:    true,
+				Operator:       cmp,
+			}
+			net.connectOutput(d)
+			sn.connectInput(d)
+
 			return tick + 1
 		}
 	case generator.ConstantValue:
 		b := &Emitter{}
 		b.items = []ConstantCombinatorItem{{
 			signal: sig,
-			count:  int32(v.Value().(uint64)),
+			count:  constantCount(v),
 		}}
 
 		net.connectOutput(b)
 
 		return tick
-	case *generator.Variable:
+	case generator.Conversion:
+		return b.convert(v, tick, net, sig)
+	case *generator.Variable, *generator.Argument:
 		cell := b.cells[v]
 
 		// TODO: optimize for multiple reads in the same expression 
@@ -772,6 +917,25 @@ func (b *block) extractValue(
 	panic(fmt.Errorf("unsupported value: %s", inspector.Inspect(typ)))
 }
 
+// The count of a signal holding v.
+func constantCount(v generator.ConstantValue) (count int32) {
+	switch val := v.Value().(type) {
+	case bool:
+		if val {
+			count = 1
+		}
+	case int64:
+		count = int32(val)
+	case uint64:
+		count = int32(val)
+	case float64:
+		// Signals can only hold integers.
+		count = int32(val)
+	}
+
+	return
+}
+
 type CellAssignment struct {
 	cell *Cell
 	val  generator.Typed
@@ -787,17 +951,21 @@ func (b *Builder) createBlock(scope *generator.Scope) *block {
 func (b *block) execStep(st generator.Step, net *Network, tick int) int {
 
 	switch s := st.(type) {
-	case *generator.Declare:
-		cell := b.createCell(net, s.Variable)
+	case generator.Declare:
+		cell := b.createCell(net, s.Name, s.Type())
+		b.cells[s.Variable] = cell
 
+		// A variable declared in a loop is zeroed each iteration.
+		subnet := b.createNet(true)
 		if s.Variable.InitialValue != nil {
-			subnet := b.createNet(true)
 			tick = b.extractValue(s.InitialValue, tick, subnet, SignalI)
-			tick = b.setCell(tick, net, SignalI, cell)
 		}
+		tick = b.setCell(tick, subnet, SignalI, cell)
 	case generator.Assign:
-		v := b.scope.Lookup(s.Target).(*generator.Variable)
-		cell := b.cells[v]
+		cell, ok := b.cells[s.Target]
+		if !ok {
+			panic(fmt.Errorf("unsupported assignment target: %s", inspector.Inspect(s.Target)))
+		}
 
 		subnet := b.createNet(true)
 		tick = b.extractValue(s.Value, tick, subnet, SignalA)
@@ -809,54 +977,328 @@ func (b *block) execStep(st generator.Step, net *Network, tick int) int {
 			tick = cb.execStep(v, net, tick)
 		}
 	case generator.If:
-		var blockStart, afterBlock *Network
-		condNet := b.createNet(true)
-		tick = b.extractValue(s.Condition, tick, condNet, SignalC)
-		// ev := b.addArithmetic(ArithmeticConditions{
-		// 	ArithmeticInputs: ArithmeticInputs{
-		// 		FirstSignal: c,
-		// 	},
-		// })
-		tnet := b.getTickerNetwork(tick, !condNet.isGreen, false)
-
-		tick, _, blockStart = b.addControlTick(!condNet.isGreen)
-		ev := &Decider{
-			InputComponent: createInputs(SignalC, Constant(0)),
-			Operator:       ComparatorNe,
-			output:         SignalCheck,
+		tick = b.lowerIf(s, net, tick)
+	case *generator.Loop:
+		tick = b.lowerLoop(s, net, tick)
+	case *generator.Switch:
+		if fansOut(s) {
+			tick = b.lowerSwitch(s, net, tick)
+		} else {
+			// Cases are checked one after the other, the same as the if/else
+			// if chain it's lowered to.
+			tick = b.execStep(s.Lower(), net, tick)
 		}
-		evo := &Decider{
-			InputComponent: createInputs(SignalC, Constant(0)),
-			Operator:       ComparatorEq,
-			output:         SignalCheck,
+	case generator.Start:
+		tick = b.start(s.Call, net, tick)
+	case generator.Break, generator.Continue:
+		panic(fmt.Errorf("unsupported break or continue: a loop can only end on its condition"))
+	// case *generator.Return
+
+	default:
+		panic(fmt.Errorf("unsupported step: %s", inspector.Inspect(st)))
+	}
+
+	return tick
+}
+
+// Checks cond after tick, adding a ticker which the pulse goes on to if it's
+// non-zero.  The returned decider passes the pulse on if it's zero instead.
+func (b *block) branch(cond generator.Typed, tick int) (int, *Decider) {
+	var blockStart *Network
+	condNet := b.createNet(true)
+	tick = b.extractValue(cond, tick, condNet, SignalC)
+	tnet := b.getTickerNetwork(tick, !condNet.isGreen, false)
+
+	tick, _, blockStart = b.addControlTick(!condNet.isGreen)
+	ev := &Decider{
+		InputComponent: createInputs(SignalC, Constant(0)),
+		Operator:       ComparatorNe,
+		output:         SignalCheck,
+	}
+	evo := &Decider{
+		InputComponent: createInputs(SignalC, Constant(0)),
+		Operator:       ComparatorEq,
+		output:         SignalCheck,
+	}
+	condNet.connectInput(ev)
+	condNet.connectInput(evo)
+	tnet.connectInput(ev)
+	tnet.connectInput(evo)
+	blockStart.connectOutput(ev)
+
+	return tick, evo
+}
+
+// Lowers an if statement: the pulse goes on to the then branch's tickers if
+// the condition is non-zero, and otherwise to the else branch's (an else if
+// being an else holding the rest of the chain), which both end on the same
+// ticker.
+func (b *block) lowerIf(s generator.If, net *Network, tick int) int {
+	var (
+		otherwise             *Decider
+		afterBlock, elseStart *Network
+	)
+
+	tick, otherwise = b.branch(s.Condition, tick)
+	tick = b.execStep(s.Then, net, tick)
+
+	if len(s.ElseIf) == 0 && s.Else == nil {
+		tick, _, afterBlock = b.addControlTick(false)
+
+		afterBlock.connectOutput(otherwise)
+		b.getTickerNetwork(tick-1, true, false).connectInput(b.tickers[tick])
+		return tick
+	}
+
+	thenEnd := len(b.tickers) - 1
+	tick, _, elseStart = b.addControlTick(false)
+	elseStart.connectOutput(otherwise)
+
+	if len(s.ElseIf) > 0 {
+		next := s.ElseIf[0]
+		next.ElseIf, next.Else = s.ElseIf[1:], s.Else
+		tick = b.lowerIf(next, net, tick)
+	} else {
+		tick = b.execStep(*s.Else, net, tick)
+	}
+
+	// The branches join on different colours, since a ticker only has one
+	// input of each.
+	elseEnd := len(b.tickers) - 1
+	tick = b.addJoinTick()
+	b.getTickerNetwork(thenEnd, true, false).connectInput(b.tickers[tick])
+	b.getTickerNetwork(elseEnd, false, false).connectInput(b.tickers[tick])
+
+	return tick
+}
+
+// Whether a switch can be fanned out on its tag (see lowerSwitch), which needs
+// every case to compare it to constants, and none to fall through.
+func fansOut(s *generator.Switch) bool {
+	if s.Tag == nil {
+		return false
+	}
+
+	hasValues := false
+
+	for _, c := range s.Cases {
+		if c.Fallthrough {
+			return false
 		}
-		condNet.connectInput(ev)
-		condNet.connectInput(evo)
-		tnet.connectInput(ev)
-		tnet.connectInput(evo)
-		blockStart.connectOutput(ev)
-		tick = b.execStep(s.Then, net, tick)
 
-		tick, _, afterBlock = b.addControlTick(!condNet.isGreen)
+		for _, val := range c.Values {
+			if _, ok := val.(generator.ConstantValue); !ok {
+				return false
+			}
 
-		afterBlock.connectOutput(evo)
-		b.getTickerNetwork(tick-1, condNet.isGreen, false).connectInput(b.tickers[tick])
+			hasValues = true
+		}
+	}
 
-		// tick, ec, sb := b.addControlTick(true)
-	// case *generator.Return
+	return hasValues
+}
 
-	default:
-		inspector.Println(st)
-		panic("unhandled step")
+// Lowers a switch on constant cases: the tag is evaluated once, and a decider
+// for each case value sends the pulse on to the tickers of the case it matches.
+// Those deciders also pass the pulse on to a network the default's decider
+// checks on the next tick, along with the ticker's own pulse, so it only passes
+// it on if no case did.  The cases and the default all end on the same ticker,
+// joined two at a time.
+func (b *block) lowerSwitch(s *generator.Switch, net *Network, tick int) int {
+	var (
+		sb   = b.createBlock(s.Scope)
+		ends []int
+		def  *generator.Block
+	)
+
+	if s.Init != nil {
+		tick = sb.execStep(s.Init, net, tick)
+	}
+
+	tagNet := b.createNet(true)
+	tick = sb.extractValue(s.Tag, tick, tagNet, SignalC)
+	tnet := b.getTickerNetwork(tick, !tagNet.isGreen, false)
+	// Created before the cases' tickers, so it's the one after tick.
+	dnet := b.getTickerNetwork(tick+1, !tagNet.isGreen, false)
+	matchNet := b.createNet(tagNet.isGreen)
+
+	for i, c := range s.Cases {
+		if c.Values == nil {
+			def = &s.Cases[i].Block
+			continue
+		}
+
+		start, _, caseStart := b.addControlTick(!tagNet.isGreen)
+
+		for _, val := range c.Values {
+			d := &Decider{
+				InputComponent: createInputs(SignalC, Constant(constantCount(val.(generator.ConstantValue)))),
+				Operator:       ComparatorEq,
+				output:         SignalCheck,
+			}
+			tagNet.connectInput(d)
+			tnet.connectInput(d)
+			caseStart.connectOutput(d)
+			matchNet.connectOutput(d)
+		}
+
+		sb.execStep(caseBlock(c.Block), net, start)
+		ends = append(ends, len(b.tickers)-1)
+	}
+
+	start, _, defStart := b.addControlTick(!tagNet.isGreen)
+	otherwise := &Decider{
+		InputComponent: createInputs(SignalCheck, Constant(1)),
+		Operator:       ComparatorEq,
+		output:         SignalCheck,
+		outputFixed:    true,
+	}
+	matchNet.connectInput(otherwise)
+	dnet.connectInput(otherwise)
+	defStart.connectOutput(otherwise)
+
+	if def != nil {
+		sb.execStep(caseBlock(*def), net, start)
+	}
+
+	ends = append(ends, len(b.tickers)-1)
+
+	// A ticker only has one input of each colour.
+	tick = b.addJoinTick()
+	b.getTickerNetwork(ends[0], true, false).connectInput(b.tickers[tick])
+	b.getTickerNetwork(ends[1], false, false).connectInput(b.tickers[tick])
+
+	for _, end := range ends[2:] {
+		joined := tick
+		tick = b.addJoinTick()
+		b.getTickerNetwork(joined, true, false).connectInput(b.tickers[tick])
+		b.getTickerNetwork(end, false, false).connectInput(b.tickers[tick])
 	}
 
 	return tick
 }
 
-func CreateBlueprint(m generator.Module, out io.Writer) error {
+// The block of a case, without the `break` it can end with, which doesn't do
+// anything there.
+func caseBlock(block generator.Block) generator.Block {
+	if n := len(block.Steps); n > 0 {
+		if _, ok := block.Steps[n-1].(generator.Break); ok {
+			block.Steps = block.Steps[:n-1]
+		}
+	}
+
+	return block
+}
+
+// Lowers a loop: the pulse from before the loop and the one from the end of
+// each iteration join on the ticker its condition is checked after, which
+// either starts the next iteration or goes on past the loop.  Nothing joins
+// the pulse from a `break` or `continue`, so they aren't supported.
+func (b *block) lowerLoop(s *generator.Loop, net *Network, tick int) int {
+	var (
+		otherwise *Decider
+		after     *Network
+		lb        = b.createBlock(s.Scope)
+	)
+
+	if s.Init != nil {
+		tick = lb.execStep(s.Init, net, tick)
+	}
+
+	entry := len(b.tickers) - 1
+	tick = b.addJoinTick()
+	check := tick
+	b.getTickerNetwork(entry, false, false).connectInput(b.tickers[check])
+
+	if s.Condition != nil {
+		tick, otherwise = lb.branch(s.Condition, tick)
+	}
+
+	tick = lb.execStep(s.Block, net, tick)
+
+	if s.Post != nil {
+		tick = lb.execStep(s.Post, net, tick)
+	}
+
+	b.getTickerNetwork(len(b.tickers)-1, true, false).connectInput(b.tickers[check])
+
+	// A loop without a condition never ends, so nothing starts the ticker
+	// after it.
+	tick, _, after = b.addControlTick(false)
+	if otherwise != nil {
+		after.connectOutput(otherwise)
+	}
+
+	return tick
+}
+
+// Runs the body of a started function on a chain of tickers of its own, which
+// is kicked off by this chain's pulse once the arguments are written to the
+// chain's cells, so both chains run in parallel.  The chains don't coordinate
+// memory operations, so both accessing a cell on the same tick corrupts it (see
+// spec.md).
+func (b *block) start(call generator.Call, net *Network, tick int) int {
+	fn := call.Target
+
+	if call.Receiver != nil {
+		panic(fmt.Errorf("unsupported start: %s() is a method", fn.Name))
+	}
+
+	for i, arg := range fn.Args {
+		if arg.Variadic {
+			panic(fmt.Errorf("unsupported start: %s() is variadic", fn.Name))
+		}
+
+		cell := b.createCell(net, arg.Name, arg.Type())
+		b.cells[arg] = cell
+
+		subnet := b.createNet(true)
+		tick = b.extractValue(call.Arguments[i], tick, subnet, SignalA)
+		tick = b.setCell(tick, subnet, SignalA, cell)
+	}
+
+	// The last write takes the tick after the one setCell returns too.
+	if len(fn.Args) > 0 {
+		tick++
+	}
+
+	kick := b.getTickerNetwork(tick, false, false)
+
+	// Ticks are numbered per chain, so the new chain replaces this one until
+	// it's built.
+	parent := b.tickers
+	b.tickers = nil
+
+	kick.connectInput(b.getTicker(0, false))
+
+	fb := b.createBlock(call.Target.Scope)
+	ftick := 0
+
+	for _, st := range call.Target.Steps {
+		ftick = fb.execStep(st, net, ftick)
+	}
+
+	b.tickers = parent
+
+	return tick + 1
+}
+
+func CreateBlueprint(m generator.Module, out io.Writer) (err error) {
+	// Steps and values which can't be lowered panic with an error.
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(error)
+			if !ok {
+				panic(r)
+			}
+
+			err = e
+		}
+	}()
+
 	var (
 		b = Builder{
-			cells: map[*generator.Variable]*Cell{},
+			cells: map[generator.Typed]*Cell{},
 		}
 
 		initSteps = make([]CellAssignment, 0, len(m.Declarations))
@@ -869,12 +1311,26 @@ func CreateBlueprint(m generator.Module, out io.Writer) error {
 	fmt.Println(len(b.networks))
 	pnet := b.createNet(false)
 
-	for _, dec := range m.Declarations {
-		if dec.Type().Kind() != generator.KindInt32 {
+	for _, step := range m.Declarations {
+		dec, ok := step.(generator.Declare)
+
+		if !ok {
+			return fmt.Errorf("unsupported declaration: %s", inspector.Inspect(step))
+		}
+
+		if dec.Type().Kind() == generator.KindString {
+			return fmt.Errorf("unsupported variable '%s' of type string: signals can only hold numbers", dec.Name)
+		}
+
+		if k := dec.Type().Kind(); k != generator.KindInt32 && k != generator.KindEnum {
 			return fmt.Errorf("invalid type: %s", dec.Type().Name())
 		}
 
-		cell := b.createCell(pnet, dec.Variable)
+		cell := b.createCell(pnet, dec.Name, dec.Type())
+
+		if enum, ok := dec.Type().(*generator.Enum); ok {
+			b.addEnumLamps(enum, cell)
+		}
 
 		bl.cells[dec.Variable] = cell
 
@@ -0,0 +1,271 @@
+package factorio
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"main/loader"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The entities of a saved blueprint, with the fields the tests check.
+type savedEntity struct {
+	Name            string `json:"name"`
+	ControlBehavior struct {
+		DeciderConditions struct {
+			FirstSignal struct {
+				Name string `json:"name"`
+			} `json:"first_signal"`
+			Comparator string `json:"comparator"`
+			Constant   int32  `json:"constant"`
+		} `json:"decider_conditions"`
+		CircuitCondition struct {
+			FirstSignal struct {
+				Name string `json:"name"`
+			} `json:"first_signal"`
+		} `json:"circuit_condition"`
+		UseColors bool `json:"use_colors"`
+	} `json:"control_behavior"`
+}
+
+// Loads src as the main module and lays it out as a blueprint, failing if
+// either doesn't work, and returns the blueprint's entities.
+func buildBlueprint(t *testing.T, src string) []savedEntity {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tbd")
+
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mods, errs := loader.New(dir).Load(path)
+
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+
+	if err := CreateBlueprint(*mods[len(mods)-1], &out); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(out.String(), "0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bp struct {
+		Blueprint struct {
+			Entities []savedEntity `json:"entities"`
+		} `json:"blueprint"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&bp); err != nil {
+		t.Fatal(err)
+	}
+
+	return bp.Blueprint.Entities
+}
+
+// The conditions of the deciders branching on the condition of an if, or the
+// tag of a switch, sorted (ie "=0" for a decider passing the pulse on if it's
+// zero).
+func branchConditions(entities []savedEntity) (conditions []string) {
+	for _, e := range entities {
+		cond := e.ControlBehavior.DeciderConditions
+
+		if e.Name == "decider-combinator" && cond.FirstSignal.Name == SignalC.Name {
+			conditions = append(conditions, cond.Comparator+fmt.Sprint(cond.Constant))
+		}
+	}
+
+	sort.Strings(conditions)
+
+	return
+}
+
+func TestBranches(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// A switch on constant cases is fanned out on its tag, rather than
+		// checking each case in turn like an if/else if chain.
+		conditions []string
+	}{
+		{"if", `
+var a int32 = 2
+
+func main() {
+	if a == 0 {
+		a = 1
+	}
+}`, []string{"=0", "≠0"}},
+		{"else if chain", `
+var a int32 = 2
+
+func main() {
+	if a == 0 {
+		a = 1
+	} else if a == 1 {
+		a = 2
+	} else if a == 2 {
+		a = 3
+	} else {
+		a = 4
+	}
+}`, []string{"=0", "=0", "=0", "≠0", "≠0", "≠0"}},
+		{"3-way switch", `
+var a int32 = 2
+
+func main() {
+	switch a {
+	case 0:
+		a = 1
+	case 1, 3:
+		a = 2
+	default:
+		a = 3
+	}
+}`, []string{"=0", "=1", "=3"}},
+		{"switch without default", `
+var a int32 = 2
+
+func main() {
+	switch a + 1 {
+	case 0:
+		a = 1
+	case 1:
+		a = 2
+	case 2:
+		a = 3
+	}
+}`, []string{"=0", "=1", "=2"}},
+		{"tagless switch", `
+var a int32 = 2
+
+func main() {
+	switch {
+	case a < 0:
+		a = 1
+	case a > 1:
+		a = 2
+	default:
+		a = 3
+	}
+}`, []string{"=0", "=0", "≠0", "≠0"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entities := buildBlueprint(t, test.src)
+
+			if got := branchConditions(entities); fmt.Sprint(got) != fmt.Sprint(test.conditions) {
+				t.Errorf("expected branches on %v; got %v", test.conditions, got)
+			}
+		})
+	}
+}
+
+// Many branches used to make the planner backtrack without end, growing the
+// plot until it ran out of memory.
+func TestManyBranches(t *testing.T) {
+	var src strings.Builder
+
+	src.WriteString("var a int32 = 2\nvar b int32 = 3\n\nfunc main() {\n")
+
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&src, "\ta = b + %d\n", i)
+
+		if i%5 == 0 {
+			fmt.Fprintf(&src, "\tif a == %d {\n\t\tb = a\n\t} else if b == 2 {\n\t\ta = b\n\t} else {\n\t\ta = 5\n\t}\n", i)
+		}
+	}
+
+	src.WriteString("}\n")
+
+	buildBlueprint(t, src.String())
+}
+
+func TestEnumLamps(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// The signals the lamps showing the variable are lit by, sorted.
+		lamps []string
+	}{
+		{"colours", `
+enum Color {
+	Red
+	Green
+	Purple
+	Gray
+}
+
+var c Color = Color.Green
+
+func main() {
+	c = Color.Purple
+}`, []string{"signal-green", "signal-grey", "signal-pink", "signal-red"}},
+		{"letters", `
+enum Grade {
+	A
+	B
+	C
+}
+
+var g Grade
+
+func main() {
+	g = Grade(2)
+}`, []string{"signal-A", "signal-B", "signal-C"}},
+		{"no signals", `
+enum State {
+	Idle
+	Busy
+}
+
+var s State
+
+func main() {
+	s = State.Busy
+}`, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var lamps []string
+
+			for _, e := range buildBlueprint(t, test.src) {
+				if e.Name != "small-lamp" {
+					continue
+				}
+
+				if !e.ControlBehavior.UseColors {
+					t.Error("expected lamps to use colours")
+				}
+
+				lamps = append(lamps, e.ControlBehavior.CircuitCondition.FirstSignal.Name)
+			}
+
+			sort.Strings(lamps)
+
+			if fmt.Sprint(lamps) != fmt.Sprint(test.lamps) {
+				t.Errorf("expected lamps lit by %v; got %v", test.lamps, lamps)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package generator
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"main/lexer"
+	"main/parser"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+// Same as go, constants are arbitrary precision: their values are
+// constant.Values, and they're only checked against the size of their type
+// once they have one.  Untyped constants carry their value in their type as
+// well, so assigning one can check it's representable by the type it's assigned
+// to.
+
+// The type of untyped integer constants, ie `1`, `'a'` or `1 << 100`.
+type untypedInt struct {
+	value constant.Value
+}
+
+// The type of untyped floating-point constants, ie `1.5` or `1 / 2.0`.
+type untypedFloat struct {
+	value constant.Value
+}
+
+func (u untypedInt) Zero() any {
+	return int64(0)
+}
+
+func (u untypedInt) Name() string {
+	return "untyped int"
+}
+
+func (u untypedInt) Kind() Kind {
+	return kindUntypedInt
+}
+
+func (u untypedInt) AssignableTo(target Type) bool {
+	return assignableConstant(u, u.value, target)
+}
+
+func (u untypedFloat) Zero() any {
+	return float64(0)
+}
+
+func (u untypedFloat) Name() string {
+	return "untyped float"
+}
+
+func (u untypedFloat) Kind() Kind {
+	return kindUntypedFloat
+}
+
+func (u untypedFloat) AssignableTo(target Type) bool {
+	return assignableConstant(u, u.value, target)
+}
+
+func assignableConstant(typ Type, v constant.Value, target Type) bool {
+	if target == nil {
+		return true
+	}
+
+	switch target.Kind() {
+	case KindInterface:
+		return unimplemented(typ, target.(*Interface)) == ""
+	case kindUntypedInt:
+		return v.Kind() == constant.Int
+	case kindUntypedFloat:
+		return true
+	}
+
+	if !target.Kind().isNumeric() {
+		return false
+	}
+
+	_, reason := representable(v, target)
+	return reason == ""
+}
+
+func isUntyped(typ Type) bool {
+	switch typ.(type) {
+	case untypedInt, untypedFloat:
+		return true
+	}
+
+	return false
+}
+
+// An untyped constant, which is an int or a float depending on v.
+func untyped(v constant.Value) ConstantValue {
+	if v.Kind() == constant.Int {
+		return ConstantValue{value: v, typ: untypedInt{v}}
+	}
+
+	return ConstantValue{value: v, typ: untypedFloat{v}}
+}
+
+// A constant of typ, where v is either a constant.Value or a go value of the
+// matching type, ie `uint64(1)` for an enum's member (or nil, for nil).
+func constantOf(v any, typ Type) ConstantValue {
+	return ConstantValue{
+		value: makeConstant(v),
+		typ:   typ,
+	}
+}
+
+func makeConstant(v any) constant.Value {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case constant.Value:
+		return v
+	}
+
+	switch v := reflect.ValueOf(v); {
+	case v.CanInt():
+		return constant.MakeInt64(v.Int())
+	case v.CanUint():
+		return constant.MakeUint64(v.Uint())
+	case v.CanFloat():
+		return constant.MakeFloat64(v.Float())
+	case v.Kind() == reflect.Bool:
+		return constant.MakeBool(v.Bool())
+	case v.Kind() == reflect.String:
+		return constant.MakeString(v.String())
+	}
+
+	panic(fmt.Errorf("not a constant: %v", v))
+}
+
+// The size of each integer type, and whether it's signed.  Same as the
+// backends, ints and uints are 32 bits.
+var integerSizes = map[Kind]struct {
+	bits   int
+	signed bool
+}{
+	KindInt:    {32, true},
+	KindInt8:   {8, true},
+	KindInt16:  {16, true},
+	KindInt32:  {32, true},
+	KindInt64:  {64, true},
+	KindUint:   {32, false},
+	KindUint8:  {8, false},
+	KindUint16: {16, false},
+	KindUint32: {32, false},
+	KindUint64: {64, false},
+}
+
+// Converts the constant v to typ, which has to be numeric.  If v isn't
+// representable by typ, returns why, ie "constant 300 overflows int8".
+func representable(v constant.Value, typ Type) (constant.Value, string) {
+	switch kind := typ.Kind(); {
+	case kind == kindUntypedFloat:
+		return constant.ToFloat(v), ""
+	case kind.isInteger():
+		i := constant.ToInt(v)
+
+		if i.Kind() != constant.Int {
+			return nil, fmt.Sprintf("constant %s truncated to integer", describeValue(v))
+		}
+
+		size, ok := integerSizes[kind]
+
+		if !ok {
+			return i, ""
+		}
+
+		n, _ := new(big.Int).SetString(i.ExactString(), 10)
+
+		switch {
+		case n.Sign() < 0 && !size.signed:
+			return nil, fmt.Sprintf("constant %s overflows %s", describeValue(v), typ.Name())
+		case n.Sign() < 0:
+			// -128 fits in an int8, same as 127.
+			n.Not(n)
+			fallthrough
+		case size.signed:
+			if n.BitLen() >= size.bits {
+				return nil, fmt.Sprintf("constant %s overflows %s", describeValue(v), typ.Name())
+			}
+		case n.BitLen() > size.bits:
+			return nil, fmt.Sprintf("constant %s overflows %s", describeValue(v), typ.Name())
+		}
+
+		return i, ""
+	case kind == KindFloat32:
+		f, _ := constant.Float32Val(constant.ToFloat(v))
+
+		if math.IsInf(float64(f), 0) {
+			return nil, fmt.Sprintf("constant %s overflows %s", describeValue(v), typ.Name())
+		}
+
+		return constant.MakeFloat64(float64(f)), ""
+	case kind == KindFloat64:
+		f, _ := constant.Float64Val(constant.ToFloat(v))
+
+		if math.IsInf(f, 0) {
+			return nil, fmt.Sprintf("constant %s overflows %s", describeValue(v), typ.Name())
+		}
+
+		return constant.MakeFloat64(f), ""
+	}
+
+	panic(fmt.Errorf("not a numeric type: %s", typ.Name()))
+}
+
+// Describes v for an error, ie `1 << 100` is 1.26765e+30 rather than all 31 of
+// its digits.
+func describeValue(v constant.Value) string {
+	if v.Kind() == constant.Int && constant.BitLen(v) > 64 {
+		return constant.ToFloat(v).String()
+	}
+
+	return v.String()
+}
+
+// The constant v as a constant of typ, or an error if typ can't represent it,
+// ie `int8(100) * 2`.  Untyped constants stay untyped.
+func (s *Scope) constantOfType(node parser.AstNode, v constant.Value, typ Type) Typed {
+	switch typ.(type) {
+	case untypedInt:
+		return untyped(v)
+	case untypedFloat:
+		return untyped(constant.ToFloat(v))
+	}
+
+	if typ.Kind().isNumeric() {
+		conv, reason := representable(v, typ)
+
+		if reason != "" {
+			s.error(node, "%s", reason)
+			return nil
+		}
+
+		v = conv
+	}
+
+	return ConstantValue{value: v, typ: typ}
+}
+
+// The constant as a go value for backends: a bool, a string, nil, or an int64,
+// uint64 (for unsigned types and enums) or float64 depending on its type.
+func (c ConstantValue) Value() any {
+	if c.value == nil {
+		return nil
+	}
+
+	switch kind := c.typ.Kind(); c.value.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(c.value)
+	case constant.String:
+		return constant.StringVal(c.value)
+	case constant.Int:
+		if kind == KindFloat32 || kind == KindFloat64 || kind == kindUntypedFloat {
+			break
+		}
+
+		if n, exact := constant.Int64Val(c.value); exact && (kind != KindEnum && !isUnsigned(kind)) {
+			return n
+		}
+
+		if n, exact := constant.Uint64Val(c.value); exact {
+			return n
+		}
+
+		// Only untyped constants can be this big, and they have to be given a
+		// type before a backend gets them.
+		panic(fmt.Errorf("constant %s overflows 64 bits", c.value))
+	}
+
+	f, _ := constant.Float64Val(constant.ToFloat(c.value))
+	return f
+}
+
+func isUnsigned(kind Kind) bool {
+	return KindUint <= kind && kind <= KindUint64
+}
+
+// Whether c is the zero value of its type, ie `false` or `0`.
+func (c ConstantValue) isZero() bool {
+	if c.value == nil {
+		return true
+	}
+
+	switch c.value.Kind() {
+	case constant.Bool:
+		return !constant.BoolVal(c.value)
+	case constant.String:
+		return constant.StringVal(c.value) == ""
+	}
+
+	return constant.Sign(c.value) == 0
+}
+
+// The go/token equivalent of each operator which can be folded by
+// constant.BinaryOp or constant.Compare.
+var constantOperators = map[lexer.Token]token.Token{
+	lexer.ADD:         token.ADD,
+	lexer.SUB:         token.SUB,
+	lexer.MUL:         token.MUL,
+	lexer.DIV:         token.QUO,
+	lexer.MOD:         token.REM,
+	lexer.AND:         token.AND,
+	lexer.OR:          token.OR,
+	lexer.XOR:         token.XOR,
+	lexer.AND_NOT:     token.AND_NOT,
+	lexer.LEFT_SHIFT:  token.SHL,
+	lexer.RIGHT_SHIFT: token.SHR,
+	lexer.EQL:         token.EQL,
+	lexer.NOT_EQL:     token.NEQ,
+	lexer.LESS:        token.LSS,
+	lexer.LESS_EQL:    token.LEQ,
+	lexer.GREATER:     token.GTR,
+	lexer.GREATER_EQL: token.GEQ,
+}
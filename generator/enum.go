@@ -0,0 +1,124 @@
+package generator
+
+import "main/parser"
+
+// A named integer type whose values are its members, ie `enum Color { Red }`.
+// Members are constants numbered from 0 in the order they were declared, and
+// are accessed through the enum (`Color.Red`).
+type Enum struct {
+	name    string
+	Members []string
+	// The methods declared on the enum, in the order they were declared.
+	Methods []*Function
+}
+
+func (e *Enum) Kind() Kind {
+	return KindEnum
+}
+
+func (e *Enum) Name() string {
+	return e.name
+}
+
+func (e *Enum) Zero() any {
+	return uint64(0)
+}
+
+// Enums are only assignable to the same enum (ints have to be converted), or
+// interfaces they implement.
+func (e *Enum) AssignableTo(other Type) bool {
+	if other == nil {
+		return true
+	}
+
+	if iface, ok := other.(*Interface); ok {
+		return unimplemented(e, iface) == ""
+	}
+
+	o, _ := other.(*Enum)
+	return e == o
+}
+
+func (e *Enum) method(name string) *Function {
+	for _, m := range e.Methods {
+		if m.Name == name {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// The constant for the member with the given name, if there is one.
+func (e *Enum) member(name string) (ConstantValue, bool) {
+	for i, member := range e.Members {
+		if member == name {
+			return constantOf(uint64(i), e), true
+		}
+	}
+
+	return ConstantValue{}, false
+}
+
+// The name of the member with the given value, ie for a backend to use in place
+// of the member's number.
+func (e *Enum) MemberName(value uint64) string {
+	if value < uint64(len(e.Members)) {
+		return e.Members[value]
+	}
+
+	return ""
+}
+
+func (s *Scope) declareEnum(node parser.EnumDeclarationNode) {
+	name := node.Name()
+	if _, ok := s.Identifiers[name]; ok {
+		s.error(node, "cannot redeclare identifier '%s'", name)
+		return
+	}
+
+	enum := &Enum{name: name, Members: make([]string, 0, len(node.Members))}
+	s.Identifiers[name] = enum
+
+	if len(node.Members) == 0 {
+		s.error(node, "enum '%s' has no members", name)
+		return
+	}
+
+	for _, member := range node.Members {
+		if _, ok := enum.member(member.Target); ok {
+			s.error(member, "duplicate member '%s' in enum '%s'", member.Target, name)
+			continue
+		}
+
+		enum.Members = append(enum.Members, member.Target)
+	}
+}
+
+// Evaluates `Enum.Member` (or `module.Enum.Member`).  Returns false if node
+// isn't an access to an enum.
+func (s *Scope) evaluateEnumMember(node parser.PropertyAccessNode) (Typed, bool) {
+	var enum *Enum
+
+	switch of := node.PropertyOf.(type) {
+	case parser.IdentifierNode:
+		enum, _ = s.Lookup(of.Target).(*Enum)
+	case parser.PropertyAccessNode:
+		if imp := s.importOf(of); imp != nil {
+			enum, _ = imp.exported(of).(*Enum)
+		}
+	}
+
+	if enum == nil {
+		return nil, false
+	}
+
+	member, ok := enum.member(node.Property.Target)
+
+	if !ok {
+		s.error(node.Property, "enum '%s' has no member '%s'", enum.Name(), node.Property.Target)
+		return nil, true
+	}
+
+	return member, true
+}
@@ -0,0 +1,66 @@
+package generator
+
+import "testing"
+
+const colors = "enum Color {\n\tRed\n\tGreen\n}\n\n"
+
+func TestEnums(t *testing.T) {
+	runSourceTests(t, []sourceTest{
+		{
+			name: "members and conversions",
+			src:  colors + "func main() {\n\tc := Color.Green\n\tvar i int = int(c)\n\tc = Color(0)\n\tvar b bool = c == Color.Red\n}\n",
+		},
+		{
+			name: "methods",
+			src:  colors + "func Color.name() string {\n\treturn \"x\"\n}\n\nfunc main() {\n\tvar s string = Color.Red.name()\n}\n",
+		},
+		{
+			name: "exhaustive switch",
+			src:  colors + "func main() {\n\tswitch Color.Red {\n\tcase Color.Red:\n\tcase Color.Green:\n\t}\n}\n",
+		},
+		{
+			name: "switch with a default",
+			src:  colors + "func main() {\n\tswitch Color.Red {\n\tcase Color.Red:\n\tdefault:\n\t}\n}\n",
+		},
+		{
+			name: "missing member",
+			src:  colors + "func main() {\n\tc := Color.Blue\n}\n",
+			err:  "enum 'Color' has no member 'Blue'",
+		},
+		{
+			name: "constant which isn't a member",
+			src:  colors + "func main() {\n\tc := Color(2)\n}\n",
+			err:  "constant 2 is not a member of enum 'Color'",
+		},
+		{
+			name: "int assigned",
+			src:  colors + "func main() {\n\tvar c Color = 1\n}\n",
+			err:  "type untyped int is unassignable to Color",
+		},
+		{
+			name: "assigned to an int",
+			src:  colors + "func main() {\n\tvar i int = Color.Red\n}\n",
+			err:  "type Color is unassignable to int",
+		},
+		{
+			name: "arithmetic",
+			src:  colors + "func main() {\n\tc := Color.Red + Color.Green\n}\n",
+			err:  "invalid operation: operator + not defined on enum",
+		},
+		{
+			name: "compared to another enum",
+			src:  colors + "enum Size {\n\tSmall\n}\n\nfunc main() {\n\tvar b bool = Color.Red == Size.Small\n}\n",
+			err:  "type mismatch: unable to resolve Color == Size",
+		},
+		{
+			name: "duplicate member",
+			src:  "enum Color {\n\tRed\n\tRed\n}\n",
+			err:  "duplicate member 'Red' in enum 'Color'",
+		},
+		{
+			name: "switch missing a member",
+			src:  colors + "func main() {\n\tswitch Color.Red {\n\tcase Color.Red:\n\t}\n}\n",
+			err:  "missing cases in switch of type 'Color': Green",
+		},
+	})
+}
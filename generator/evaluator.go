@@ -2,9 +2,11 @@ package generator
 
 import (
 	"fmt"
+	"go/constant"
 	"main/lexer"
 	"main/parser"
 	"reflect"
+	"unicode/utf8"
 )
 
 type Typed interface {
@@ -40,9 +42,26 @@ func (b BinaryOperation) Type() Type {
 	if b.Left == nil {
 		return &Generic{kind: invalid}
 	}
+	if isComparison(b.Operator) || isLogical(b.Operator) {
+		return genericBool
+	}
 	return b.Left.Type()
 }
 
+func isComparison(op lexer.Token) bool {
+	switch op {
+	case lexer.EQL, lexer.NOT_EQL, lexer.LESS, lexer.LESS_EQL, lexer.GREATER, lexer.GREATER_EQL:
+		return true
+	}
+
+	return false
+}
+
+// Whether op is `&&` or `||`, which are only defined on bools.
+func isLogical(op lexer.Token) bool {
+	return op == lexer.BOOLEAN_AND || op == lexer.BOOLEAN_OR
+}
+
 func (s *Scope) evaluateBinaryExpression(node parser.BinaryOperationNode) Typed {
 	left := s.preEvaluate(node.Left)
 
@@ -56,22 +75,98 @@ func (s *Scope) evaluateBinaryExpression(node parser.BinaryOperationNode) Typed
 		return nil
 	}
 
-	if !right.Type().AssignableTo(left.Type()) {
+	if node.Operator == lexer.LEFT_SHIFT || node.Operator == lexer.RIGHT_SHIFT {
+		return s.evaluateShift(node, left, right)
+	}
+
+	if left, right = s.matchConstants(node, left, right); left == nil || right == nil {
+		return nil
+	}
+
+	// Same as assigning, the smaller of two numbers is converted to the type of
+	// the other, ie `int8 + int16` is an int16.
+	if lt, rt := left.Type(), right.Type(); !isUntyped(lt) && lt != rt && lt.Kind().isNumeric() && rt.Kind().isNumeric() {
+		switch {
+		case rt.AssignableTo(lt):
+			right = assignedTo(right, lt)
+		case lt.AssignableTo(rt):
+			left = assignedTo(left, rt)
+		}
+	}
+
+	// `nil == a` is the same as `a == nil`.
+	if !right.Type().AssignableTo(left.Type()) && !(isNil(left) && left.Type().AssignableTo(right.Type())) {
 		s.error(node, "type mismatch: unable to resolve %s %s %s", left.Type().Name(), node.Operator.String(), right.Type().Name())
 		return nil
 	}
 
+	// Same as go, interfaces and slices can be compared to nil.
+	if isNil(left) || isNil(right) {
+		switch {
+		case isNil(left) && isNil(right):
+			s.error(node, "invalid operation: operator %s not defined on nil", node.Operator)
+			return nil
+		case node.Operator != lexer.EQL && node.Operator != lexer.NOT_EQL:
+			s.error(node, "invalid operation: operator %s not defined on nil", node.Operator)
+			return nil
+		}
+
+		return BinaryOperation{
+			Left:     left,
+			Right:    right,
+			Operator: node.Operator,
+		}
+	}
+
+	switch kind := left.Type().Kind(); {
+	case isLogical(node.Operator) && kind != KindBool:
+		s.error(node, "invalid operation: operator %s not defined on %s", node.Operator, left.Type().Name())
+		return nil
+	case kind == KindBool && !isLogical(node.Operator) && node.Operator != lexer.EQL && node.Operator != lexer.NOT_EQL:
+		s.error(node, "invalid operation: operator %s not defined on bool", node.Operator)
+		return nil
+	case kind == KindStruct && node.Operator != lexer.EQL && node.Operator != lexer.NOT_EQL:
+		s.error(node, "invalid operation: operator %s not defined on struct", node.Operator)
+		return nil
+	case kind == KindEnum && !isComparison(node.Operator):
+		s.error(node, "invalid operation: operator %s not defined on enum", node.Operator)
+		return nil
+	case kind == KindInterface:
+		s.error(node, "invalid operation: operator %s not defined on interface", node.Operator)
+		return nil
+	case kind == KindSlice:
+		s.error(node, "invalid operation: slice can only be compared to nil")
+		return nil
+	case kind == KindArray && node.Operator != lexer.EQL && node.Operator != lexer.NOT_EQL:
+		s.error(node, "invalid operation: operator %s not defined on array", node.Operator)
+		return nil
+	case kind == KindString && node.Operator != lexer.ADD && !isComparison(node.Operator):
+		s.error(node, "invalid operation: operator %s not defined on string", node.Operator)
+		return nil
+	case kind.isNumeric() && !kind.isInteger() && isBitwise(node.Operator):
+		s.error(node, "invalid operation: operator %s not defined on %s", node.Operator, left.Type().Name())
+		return nil
+	}
+
+	// Same as go, dividing a float variable by zero is fine (it's infinite).
+	if divisor, ok := right.(ConstantValue); ok && (node.Operator == lexer.DIV || node.Operator == lexer.MOD) && divisor.isZero() {
+		if isConstant(left) || left.Type().Kind().isInteger() {
+			s.error(node.Right, "invalid operation: division by zero")
+			return nil
+		}
+	}
+
 	if isConstant(left) && isConstant(right) {
 		left, right := left.(ConstantValue), right.(ConstantValue)
 		if node.Operator == lexer.BOOLEAN_AND {
-			if reflect.ValueOf(left.Value()).IsZero() {
+			if !constant.BoolVal(left.value) {
 				return left
 			}
 			return right
 		}
 
 		if node.Operator == lexer.BOOLEAN_OR {
-			if reflect.ValueOf(left.Value()).IsZero() {
+			if !constant.BoolVal(left.value) {
 				return right
 			}
 			return left
@@ -87,6 +182,95 @@ func (s *Scope) evaluateBinaryExpression(node parser.BinaryOperationNode) Typed
 	}
 }
 
+// Whether op is only defined on integers, ie `%` or `&`.
+func isBitwise(op lexer.Token) bool {
+	switch op {
+	case lexer.MOD, lexer.AND, lexer.OR, lexer.XOR, lexer.AND_NOT:
+		return true
+	}
+
+	return false
+}
+
+// Gives an untyped constant operand the type of the other operand, so `x + 1`
+// is an int8 if x is (and `x + 300` is reported).  If both operands are untyped
+// constants and either is a float, they both are.
+func (s *Scope) matchConstants(node parser.BinaryOperationNode, left, right Typed) (Typed, Typed) {
+	switch l, r := isUntyped(left.Type()), isUntyped(right.Type()); {
+	case l && r:
+		if left.Type().Kind() != right.Type().Kind() {
+			left = untyped(constant.ToFloat(left.(ConstantValue).value))
+			right = untyped(constant.ToFloat(right.(ConstantValue).value))
+		}
+	case l && right.Type().Kind().isNumeric():
+		left = s.constantOfType(node.Left, left.(ConstantValue).value, right.Type())
+	case r && left.Type().Kind().isNumeric():
+		right = s.constantOfType(node.Right, right.(ConstantValue).value, left.Type())
+	}
+
+	return left, right
+}
+
+// Evaluates `left << right` or `left >> right`.  Same as go, the count can be
+// any integer (as long as it isn't a negative constant), and the result has the
+// type of left.
+func (s *Scope) evaluateShift(node parser.BinaryOperationNode, left, right Typed) Typed {
+	count, constCount := right.(ConstantValue)
+
+	// Untyped floats can be used as integers if they are one, ie `1.0 << 2.0`.
+	if constCount && right.Type().Kind() == kindUntypedFloat {
+		if v := constant.ToInt(count.value); v.Kind() == constant.Int {
+			count = untyped(v)
+			right = count
+		}
+	}
+
+	if !right.Type().Kind().isInteger() {
+		s.error(node.Right, "invalid operation: shift count type %s, must be integer", right.Type().Name())
+		return nil
+	}
+
+	if constCount && constant.Sign(count.value) < 0 {
+		s.error(node.Right, "invalid operation: negative shift count %s", describeValue(count.value))
+		return nil
+	}
+
+	if c, ok := left.(ConstantValue); ok && isUntyped(left.Type()) {
+		if v := constant.ToInt(c.value); v.Kind() == constant.Int {
+			left = untyped(v)
+		}
+
+		// The result of a shift has to have a size if the count isn't known,
+		// so the left is an int the same as `var x = 1`.
+		if !constCount {
+			if left = s.constantOfType(node.Left, c.value, genericInt); left == nil {
+				return nil
+			}
+		}
+	}
+
+	if !left.Type().Kind().isInteger() {
+		s.error(node, "invalid operation: operator %s not defined on %s", node.Operator, left.Type().Name())
+		return nil
+	}
+
+	if c, ok := left.(ConstantValue); ok && constCount {
+		return s.resolveShift(c, count, node)
+	}
+
+	if constCount && isUntyped(right.Type()) {
+		if right = s.constantOfType(node.Right, count.value, genericUint); right == nil {
+			return nil
+		}
+	}
+
+	return BinaryOperation{
+		Left:     left,
+		Right:    right,
+		Operator: node.Operator,
+	}
+}
+
 func (s *Scope) evaluateUnaryExpression(node parser.UnaryOperationNode) Typed {
 	operand := s.preEvaluate(node.Operand)
 
@@ -94,6 +278,30 @@ func (s *Scope) evaluateUnaryExpression(node parser.UnaryOperationNode) Typed {
 		return nil
 	}
 
+	switch kind := operand.Type().Kind(); {
+	case node.Operator == lexer.NOT && kind != KindBool:
+		s.error(node, "invalid operation: operator ! not defined on %s", operand.Type().Name())
+		return nil
+	case kind == KindBool && node.Operator != lexer.NOT:
+		s.error(node, "invalid operation: operator %s not defined on bool", node.Operator)
+		return nil
+	case kind == KindStruct:
+		s.error(node, "invalid operation: operator %s not defined on struct", node.Operator)
+		return nil
+	case kind == KindEnum:
+		s.error(node, "invalid operation: operator %s not defined on enum", node.Operator)
+		return nil
+	case kind == KindInterface:
+		s.error(node, "invalid operation: operator %s not defined on interface", node.Operator)
+		return nil
+	case (node.Operator == lexer.ADD || node.Operator == lexer.SUB) && !kind.isNumeric():
+		s.error(node, "invalid operation: operator %s not defined on %s", node.Operator, operand.Type().Name())
+		return nil
+	case node.Operator == lexer.TILDE && !kind.isInteger():
+		s.error(node, "invalid operation: operator %s not defined on %s", node.Operator, operand.Type().Name())
+		return nil
+	}
+
 	if operand, ok := operand.(ConstantValue); ok {
 		return s.resolveUnaryOperation(operand, node)
 	}
@@ -105,35 +313,121 @@ func (s *Scope) evaluateUnaryExpression(node parser.UnaryOperationNode) Typed {
 }
 
 func (s *Scope) evaluateUnarySuffixExpression(node parser.SuffixUnaryOperationNode) Typed {
-	ident, ok := node.Operand.(parser.IdentifierNode)
-
-	if !ok {
+	switch node.Operand.(type) {
+	case parser.IdentifierNode, parser.PropertyAccessNode, parser.IndexNode:
+	default:
 		s.error(node, "expected a name or identifier")
 		return nil
 	}
 
-	val := s.lookupTyped(ident)
+	writeable := s.lookupWriteable(node.Operand)
 
-	if val == nil {
+	if writeable == nil {
 		return nil
 	}
 
-	if !val.Type().Kind().isNumeric() {
-		s.error(node, "invalid operation for type %s: %s", val.Type().Name(), node.Operator)
+	if !writeable.Type().Kind().isNumeric() {
+		s.error(node, "invalid operation for type %s: %s", writeable.Type().Name(), node.Operator)
 		return nil
 	}
 
-	writeable, ok := val.(Writeable)
+	return SuffixUnaryOperation{
+		Target:   writeable,
+		Operator: node.Operator,
+	}
+}
+
+// The type node names, if it names one, ie the `T` in `T(x)`.
+func (s *Scope) typeNamed(node parser.ValueNode) Type {
+	if qualified, ok := node.(parser.PropertyAccessNode); ok {
+		if imp := s.importOf(qualified); imp != nil {
+			typ, _ := imp.exported(qualified).(Type)
+			return typ
+		}
+	}
+
+	ident, ok := node.(parser.IdentifierNode)
 
 	if !ok {
-		s.error(node, "not writeable: %s", ident.Target)
 		return nil
 	}
 
-	return SuffixUnaryOperation{
-		Target:   writeable,
-		Operator: node.Operator,
+	switch named := s.Lookup(ident.Target).(type) {
+	case Type:
+		return named
+	case nil:
+		if typ, ok := Generics[ident.Target]; ok {
+			return typ
+		}
 	}
+
+	return nil
+}
+
+// Evaluates `T(x)`.  Same as go, numbers can be converted to any numeric type
+// (integers wrap around and floats are truncated), integers to strings (as a
+// rune, ie `string(rune(65))` is "A"), and enums to and from integers.
+func (s *Scope) evaluateConversion(typ Type, node parser.CallNode) Typed {
+	if len(node.Arguments) != 1 {
+		s.error(node, "expected exactly one value to convert to '%s'", typ.Name())
+		return nil
+	}
+
+	val := s.preEvaluate(node.Arguments[0])
+
+	if val == nil {
+		return nil
+	}
+
+	from := val.Type()
+
+	switch {
+	case from.AssignableTo(typ) && !isUntyped(from):
+	case from.Kind().isNumeric() && typ.Kind().isNumeric():
+	case from.Kind().isInteger() && typ.Kind() == KindString:
+	case from.Kind().isInteger() && typ.Kind() == KindEnum:
+	case from.Kind() == KindEnum && typ.Kind().isInteger():
+	default:
+		s.error(node, "cannot convert value of type '%s' to '%s'", from.Name(), typ.Name())
+		return nil
+	}
+
+	c, ok := val.(ConstantValue)
+
+	switch {
+	case !ok && typ.Kind() == KindInterface:
+		return assignedTo(val, typ)
+	case !ok:
+		return Conversion{Value: val, typ: typ}
+	case typ.Kind() == KindString && from.Kind().isInteger():
+		// Same as go, integers which aren't valid code points are "\uFFFD".
+		r := utf8.RuneError
+
+		if n, ok := constant.Int64Val(c.value); ok && n <= utf8.MaxRune && utf8.ValidRune(rune(n)) {
+			r = rune(n)
+		}
+
+		return constantOf(string(r), typ)
+	}
+
+	if enum, ok := typ.(*Enum); ok {
+		member, valid := constant.Uint64Val(c.value)
+
+		if !valid || member >= uint64(len(enum.Members)) {
+			s.error(node, "constant %s is not a member of enum '%s'", c.value, enum.Name())
+			return nil
+		}
+
+		return constantOf(member, typ)
+	}
+
+	// Same as go, constants don't wrap around or get truncated: `int8(300)` and
+	// `int(1.5)` are reported.
+	if typ.Kind().isNumeric() {
+		return s.constantOfType(node, c.value, typ)
+	}
+
+	return constantOf(c.value, typ)
 }
 
 func (s *Scope) preEvaluate(val parser.ValueNode) Typed {
@@ -149,27 +443,65 @@ func (s *Scope) preEvaluate(val parser.ValueNode) Typed {
 	case parser.IdentifierNode:
 		return s.lookupTyped(node)
 	case parser.StringNode:
-		return ConstantValue{
-			value: node.Value,
-			typ:   genericString,
-		}
+		return constantOf(node.Value, genericString)
 	case parser.IntegerNode:
-		return ConstantValue{
-			value: node.Value,
-			typ: untypedInt{
-				bits: node.Bits,
-			},
-		}
+		return untyped(node.Value)
+	case parser.CharNode:
+		return untyped(constant.MakeInt64(int64(node.Value)))
 	case parser.FloatNode:
-		// todo:  probably worth making an untyped float
-		return ConstantValue{
-			value: node.Value,
-			typ:   genericFloat64,
-		}
+		return untyped(node.Value)
 	case parser.CallNode:
-		return s.handleCall(node)
+		if typ := s.typeNamed(node.Callee); typ != nil {
+			return s.evaluateConversion(typ, node)
+		}
+
+		if fn := s.builtinNamed(node.Callee); fn != "" {
+			return s.evaluateBuiltin(fn, node)
+		}
+
+		call := s.handleCall(node)
+
+		if call.Target == nil {
+			return nil
+		}
+
+		switch typ := call.Type(); {
+		case typ == nil:
+			s.error(node, "%s() (no value) used as value", call.Target.Name)
+			return nil
+		case typ.Kind() == KindTuple:
+			s.error(node, "multiple-value %s() (value of type '%s') in single-value context", call.Target.Name, typ.Name())
+			return nil
+		}
+
+		return call
+	case parser.PropertyAccessNode:
+		return s.evaluatePropertyAccess(node)
+	case parser.CompositeValueNode:
+		return s.evaluateComposite(node)
+	case parser.TypeAssertionNode:
+		return s.evaluateTypeAssertion(node)
+	case parser.NilNode:
+		return ConstantValue{typ: untypedNil{}}
+	case parser.ArrayValueNode:
+		return s.evaluateArray(node)
+	case parser.SliceValueNode:
+		typ := s.getType(node.Prefix)
+
+		if typ == nil {
+			return nil
+		}
+
+		return s.evaluateList(typ, node, node.Elements)
+	case parser.IndexNode:
+		return s.evaluateIndex(node)
+	case parser.ElementsNode:
+		// Only the elements of arrays and slices can leave out their type.
+		s.error(node, "missing type in composite literal")
+		return nil
+	case parser.SliceNode:
+		return s.evaluateSlice(node)
 	default:
-		// TODO: Slice, struct, index, etc.
 		panic(fmt.Errorf("not implemented: evaluate %s", reflect.TypeOf(val).Name()))
 	}
 }
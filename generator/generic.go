@@ -16,6 +16,8 @@ func (g Generic) Kind() Kind {
 
 func (g Generic) Zero() any {
 	switch g.kind {
+	case KindInt:
+		return int(0)
 	case KindInt8:
 		return int8(0)
 	case KindInt16:
@@ -24,6 +26,8 @@ func (g Generic) Zero() any {
 		return int32(0)
 	case KindInt64:
 		return int64(0)
+	case KindUint:
+		return uint(0)
 	case KindUint8:
 		return uint8(0)
 	case KindUint16:
@@ -58,7 +62,7 @@ func (g Generic) Name() string {
 	case KindInt64:
 		return "int64"
 	case KindUint:
-		return "uint64"
+		return "uint"
 	case KindUint8:
 		return "uint8"
 	case KindUint16:
@@ -71,6 +75,8 @@ func (g Generic) Name() string {
 		return "float32"
 	case KindFloat64:
 		return "float64"
+	case KindString:
+		return "string"
 	case KindBool:
 		return "bool"
 	}
@@ -78,14 +84,46 @@ func (g Generic) Name() string {
 	panic(errors.New("not a Generic"))
 }
 
-// TODO: later it might be handy if we can assign a value of a lower byte count
-// to a value with a higher one - eg assign an int8 to an int16
+// Numbers can also be assigned to a bigger type which holds every value of
+// theirs, ie an int8 to an int16 (which converts them).
 func (g *Generic) AssignableTo(other Type) bool {
 	if other == nil {
 		return true
 	}
+	if iface, ok := other.(*Interface); ok {
+		return unimplemented(g, iface) == ""
+	}
 	v, _ := other.(*Generic)
-	return g == v
+	return g == v || v != nil && widens(g.kind, v.kind)
+}
+
+// Whether every value of from is also a value of to, ie int8 to int16, uint8 to
+// int16 or int16 to float32, but not int8 to uint16 or int64 to float64.
+func widens(from, to Kind) bool {
+	switch {
+	case from == KindFloat32:
+		return to == KindFloat64
+	case !from.isInteger() || !to.isNumeric():
+		return false
+	}
+
+	size := integerSizes[from]
+
+	// Floats can hold integers as big as their mantissa.
+	switch to {
+	case KindFloat32:
+		return size.bits <= 16
+	case KindFloat64:
+		return size.bits <= 32
+	}
+
+	into := integerSizes[to]
+
+	if size.signed && !into.signed {
+		return false
+	}
+
+	return size.bits < into.bits
 }
 
 func (k Kind) InspectCustom() inspector.InspectString {
@@ -116,6 +154,8 @@ func (k Kind) InspectCustom() inspector.InspectString {
 		return "float32"
 	case KindFloat64:
 		return "float64"
+	case KindBool:
+		return "bool"
 	default:
 		return inspector.InspectString(fmt.Sprintf("kind: %d", k))
 	}
@@ -162,4 +202,20 @@ var Generics = map[string]*Generic{
 	"float64": genericFloat64,
 
 	"bool": genericBool,
+
+	// Same as go, these are the same types as uint8 and int32.
+	"byte": genericUint8,
+	"rune": genericInt32,
 }
+
+// Identifiers declared in every module, other than the basic types.
+var predeclared = map[string]any{
+	"error": errorType,
+	"true":  constantOf(true, genericBool),
+	"false": constantOf(false, genericBool),
+	"len":   builtin("len"),
+	"cap":   builtin("cap"),
+}
+
+// A function declared in every module which can't be declared in tbd, ie `len`.
+type builtin string
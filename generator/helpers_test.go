@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"go/token"
+	"main/parser"
+	"strings"
+	"testing"
+)
+
+// Processes src as a module, with the modules it imports looked up by path in
+// imports, and returns it along with its errors.
+func processModule(t *testing.T, src string, imports map[string]*Module) Module {
+	t.Helper()
+
+	file := token.NewFileSet().AddFile("test.tbd", -1, len(src))
+	ast, errs := parser.NewParser([]byte(src), file).ParseModule()
+
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	return ProcessModule(ast, imports)
+}
+
+// Processes src as a module which imports nothing, failing if it has errors.
+func processSource(t *testing.T, src string) Module {
+	t.Helper()
+
+	mod := processModule(t, src, nil)
+
+	for _, err := range mod.Errors {
+		t.Fatal(err.Error())
+	}
+
+	return mod
+}
+
+// A program which should either be accepted, or give an error containing err.
+type sourceTest struct {
+	name string
+	src  string
+	err  string
+}
+
+func runSourceTests(t *testing.T, tests []sourceTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkErrors(t, processModule(t, test.src, nil), test.err)
+		})
+	}
+}
+
+// Checks mod has no errors if err is empty, and otherwise has one containing
+// it.
+func checkErrors(t *testing.T, mod Module, err string) {
+	t.Helper()
+
+	if err == "" {
+		for _, e := range mod.Errors {
+			t.Errorf("unexpected error: %s", e.Error())
+		}
+
+		return
+	}
+
+	for _, e := range mod.Errors {
+		if strings.Contains(e.Error(), err) {
+			return
+		}
+	}
+
+	var got []string
+
+	for _, e := range mod.Errors {
+		got = append(got, e.Error())
+	}
+
+	t.Errorf("errors %q; want one containing %q", got, err)
+}
@@ -0,0 +1,44 @@
+package generator
+
+import "testing"
+
+func TestIfInit(t *testing.T) {
+	runSourceTests(t, []sourceTest{
+		{
+			name: "scoped to every branch",
+			src:  "func f() int {\n\treturn 1\n}\n\nfunc main() {\n\tif x := f(); x > 0 {\n\t\tx = 2\n\t} else if y := x + 1; y > 0 {\n\t\ty = x\n\t} else {\n\t\tx = y\n\t}\n}\n",
+		},
+		{
+			name: "assignment",
+			src:  "func main() {\n\ta := 0\n\tif a = 1; a > 0 {\n\t}\n}\n",
+		},
+		{
+			name: "shadowing",
+			src:  "func main() {\n\tx := \"a\"\n\tif x := 1; x > 0 {\n\t\tx = 2\n\t}\n\tx = \"b\"\n}\n",
+		},
+		{
+			name: "shadowed in a branch",
+			src:  "func main() {\n\tif x := 1; x > 0 {\n\t\tx := \"s\"\n\t\tx = \"t\"\n\t}\n}\n",
+		},
+		{
+			name: "used after the if",
+			src:  "func main() {\n\tif x := 1; x > 0 {\n\t}\n\tx = 2\n}\n",
+			err:  "unable to resolve name: x",
+		},
+		{
+			name: "else if's used after the if",
+			src:  "func main() {\n\tif x := 1; x > 0 {\n\t} else if y := 2; y > 0 {\n\t}\n\ty = 2\n}\n",
+			err:  "unable to resolve name: y",
+		},
+		{
+			name: "branch's used in another branch",
+			src:  "func main() {\n\tif x := 1; x > 0 {\n\t\ty := 1\n\t} else {\n\t\tx = y\n\t}\n}\n",
+			err:  "unable to resolve name: y",
+		},
+		{
+			name: "condition isn't a bool",
+			src:  "func main() {\n\tif x := 1; x {\n\t}\n}\n",
+			err:  "invalid if condition: expected a bool; received value of type 'int'",
+		},
+	})
+}
@@ -0,0 +1,303 @@
+package generator
+
+import (
+	"fmt"
+	"main/parser"
+	"strings"
+)
+
+// An interface type.  Any type whose method set includes the interface's
+// methods satisfies it, without having to say so.
+type Interface struct {
+	name string
+	// The methods of the interface, including those of embedded interfaces.
+	Methods []*Function
+}
+
+func (i *Interface) Kind() Kind {
+	return KindInterface
+}
+
+func (i *Interface) Name() string {
+	return i.name
+}
+
+func (i *Interface) Zero() any {
+	return nil
+}
+
+// Interfaces are assignable to any interface whose methods they include.
+func (i *Interface) AssignableTo(other Type) bool {
+	if other == nil {
+		return true
+	}
+
+	o, ok := other.(*Interface)
+	return ok && unimplemented(i, o) == ""
+}
+
+func (i *Interface) method(name string) *Function {
+	for _, m := range i.Methods {
+		if m.Name == name {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// A value of a concrete type stored in an interface.
+type InterfaceValue struct {
+	Value Typed
+	typ   *Interface
+}
+
+func (i InterfaceValue) Type() Type {
+	return i.typ
+}
+
+// The value stored in an interface, ie `a.(T)`.  Fails at runtime if the value
+// isn't a T (or, for an interface, doesn't implement T), unless it's assigned
+// along with whether it is, ie `v, ok := a.(T)`, which gives T's zero value and
+// false instead.
+type TypeAssertion struct {
+	Value Typed
+	// Whether the assertion gives a (T, bool) tuple.
+	CommaOk bool
+	typ     Type
+}
+
+func (t TypeAssertion) Type() Type {
+	if t.CommaOk {
+		return &Tuple{Types: []Type{t.typ, genericBool}}
+	}
+
+	return t.typ
+}
+
+// The type asserted by t.
+func (t TypeAssertion) Asserted() Type {
+	return t.typ
+}
+
+// The method of typ with the given name, including methods promoted from
+// embedded structs.
+func methodOf(typ Type, name string) *Function {
+	switch typ := typ.(type) {
+	case *Struct:
+		_, method, _ := typ.lookupSelector(name)
+		return method
+	case *Enum:
+		return typ.method(name)
+	case *Interface:
+		return typ.method(name)
+	}
+
+	return nil
+}
+
+func sameSignature(a, b *Function) bool {
+	if len(a.Args) != len(b.Args) || !identical(a.Returns, b.Returns) {
+		return false
+	}
+
+	for i, arg := range a.Args {
+		if !identical(arg.Type(), b.Args[i].Type()) || arg.Variadic != b.Args[i].Variadic {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Why typ doesn't satisfy iface, ie the methods it's missing.  Empty if it
+// does.
+//
+// Same as go, methods with pointer receivers aren't part of a value's method
+// set; the value in an interface is a copy, so they'd have nothing to modify.
+func unimplemented(typ Type, iface *Interface) string {
+	var missing, reasons []string
+
+	for _, m := range iface.Methods {
+		switch fn := methodOf(typ, m.Name); {
+		case fn == nil:
+			missing = append(missing, m.Name)
+		case fn.Receiver.Ptr:
+			reasons = append(reasons, fmt.Sprintf("method %s has a pointer receiver", m.Name))
+		case !sameSignature(fn, m):
+			reasons = append(reasons, fmt.Sprintf("wrong type for method %s", m.Name))
+		}
+	}
+
+	switch len(missing) {
+	case 0:
+	case 1:
+		reasons = append([]string{"missing method " + missing[0]}, reasons...)
+	default:
+		reasons = append([]string{"missing methods " + strings.Join(missing, ", ")}, reasons...)
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+// Explains why a value of type from can't be assigned to type to, for when
+// there's more to it than the types being different.  Meant to be appended to
+// the error.
+func unassignable(from, to Type) string {
+	if iface, ok := to.(*Interface); ok {
+		if reason := unimplemented(from, iface); reason != "" {
+			return fmt.Sprintf(": %s does not implement %s (%s)", from.Name(), iface.Name(), reason)
+		}
+	}
+
+	if to != nil && to.Kind().isNumeric() {
+		switch from := from.(type) {
+		case untypedInt:
+			_, reason := representable(from.value, to)
+			return prefixed(reason)
+		case untypedFloat:
+			_, reason := representable(from.value, to)
+			return prefixed(reason)
+		}
+	}
+
+	return ""
+}
+
+// ": reason", or nothing if there's no reason.
+func prefixed(reason string) string {
+	if reason == "" {
+		return ""
+	}
+
+	return ": " + reason
+}
+
+// Stores val in an InterfaceValue if it's being assigned to an interface, so
+// backends know which type's methods to call.
+func assignedTo(val Typed, typ Type) Typed {
+	c, isConst := val.(ConstantValue)
+
+	// Numbers are converted to the type they're assigned to, so backends know
+	// how big they are, ie untyped constants or an int8 assigned to an int16.
+	if typ != nil && typ.Kind().isNumeric() && !isUntyped(typ) && val.Type() != typ {
+		if !isConst {
+			return Conversion{Value: val, typ: typ}
+		}
+
+		// nil isn't a number; the caller reports it as unassignable.
+		if c.value == nil {
+			return val
+		}
+
+		v, _ := representable(c.value, typ)
+		return ConstantValue{value: v, typ: typ}
+	}
+
+	iface, ok := typ.(*Interface)
+
+	if !ok || val.Type().Kind() == KindInterface || isNil(val) {
+		return val
+	}
+
+	// Same as go, untyped constants are ints (or float64s) once they're in an
+	// interface.
+	switch {
+	case isConst && c.typ.Kind() == kindUntypedInt:
+		val = constantOf(c.value, genericInt)
+	case isConst && c.typ.Kind() == kindUntypedFloat:
+		val = constantOf(c.value, genericFloat64)
+	}
+
+	return InterfaceValue{Value: val, typ: iface}
+}
+
+func (s *Scope) declareInterface(node parser.InterfaceDeclarationNode) {
+	name := node.Name()
+	if _, ok := s.Identifiers[name]; ok {
+		s.error(node, "cannot redeclare identifier '%s'", name)
+		return
+	}
+
+	iface := &Interface{name: name}
+	s.Identifiers[name] = iface
+
+	add := func(at parser.AstNode, fn *Function) {
+		if existing := iface.method(fn.Name); existing != nil {
+			// Same as go, embedded interfaces can overlap.
+			if !sameSignature(existing, fn) {
+				s.error(at, "duplicate method '%s' in interface '%s'", fn.Name, name)
+			}
+			return
+		}
+
+		iface.Methods = append(iface.Methods, fn)
+	}
+
+	for _, embedded := range node.Embedded {
+		typ := s.lookupType(embedded)
+
+		if typ == nil {
+			continue
+		}
+
+		other, ok := typ.(*Interface)
+
+		chain := s.resolvingType(typ)
+
+		switch {
+		case !ok:
+			s.error(embedded, "interface '%s' embeds non-interface type '%s'", name, typ.Name())
+		case chain != nil:
+			s.error(embedded, "invalid recursive type '%s': %s", chain[0], describeCycle(append(chain, chain[0])))
+		default:
+			for _, fn := range other.Methods {
+				add(embedded, fn)
+			}
+		}
+	}
+
+	for _, method := range node.Methods {
+		fn := &Function{
+			p:        s,
+			Name:     method.Name(),
+			Args:     s.getArguments(method.Arguments.Arguments),
+			Receiver: &Receiver{typ: iface},
+		}
+
+		fn.Returns = resultsType(s.getArguments(method.Results))
+
+		add(method, fn)
+	}
+}
+
+func (s *Scope) evaluateTypeAssertion(node parser.TypeAssertionNode) Typed {
+	val := s.preEvaluate(node.Value)
+
+	if val == nil {
+		return nil
+	}
+
+	iface, ok := val.Type().(*Interface)
+
+	if !ok {
+		s.error(node.Value, "invalid type assertion: value of type '%s' is not an interface", val.Type().Name())
+		return nil
+	}
+
+	typ := s.getType(node.Type)
+
+	if typ == nil {
+		return nil
+	}
+
+	// An interface could be satisfied by some other type with the methods.
+	if typ.Kind() != KindInterface {
+		if reason := unimplemented(typ, iface); reason != "" {
+			s.error(node, "impossible type assertion: %s does not implement %s (%s)", typ.Name(), iface.Name(), reason)
+			return nil
+		}
+	}
+
+	return TypeAssertion{Value: val, typ: typ}
+}
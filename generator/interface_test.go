@@ -0,0 +1,50 @@
+package generator
+
+import "testing"
+
+const shapes = "interface shape {\n\tarea() int\n}\n\nstruct square {\n\tn int\n}\n\nfunc square.area() int {\n\treturn 1\n}\n\n"
+
+func TestTypeAssertions(t *testing.T) {
+	runSourceTests(t, []sourceTest{
+		{
+			name: "assertion",
+			src:  shapes + "func main() {\n\tvar s shape = square{}\n\tq := s.(square)\n}\n",
+		},
+		{
+			name: "comma ok",
+			src:  shapes + "func main() {\n\tvar s shape = square{}\n\tq, ok := s.(square)\n\tvar n int = q.n\n\tvar b bool = ok\n}\n",
+		},
+		{
+			name: "comma ok declaration",
+			src:  shapes + "func main() {\n\tvar s shape = square{}\n\tvar q, ok = s.(square)\n}\n",
+		},
+		{
+			name: "comma ok assignment",
+			src:  shapes + "func main() {\n\tvar s shape = square{}\n\tvar q square\n\tok := false\n\tq, ok = s.(square)\n\t_, ok = s.(shape)\n}\n",
+		},
+		{
+			name: "comma ok in if",
+			src:  shapes + "func main() {\n\tvar s shape = square{}\n\tif q, ok := s.(square); ok {\n\t\tq.n = 1\n\t}\n}\n",
+		},
+		{
+			name: "comma ok of a non-interface",
+			src:  shapes + "func main() {\n\tq, ok := square{}.(square)\n}\n",
+			err:  "is not an interface",
+		},
+		{
+			name: "impossible comma ok",
+			src:  shapes + "func main() {\n\tvar s shape = square{}\n\tq, ok := s.(int)\n}\n",
+			err:  "impossible type assertion",
+		},
+		{
+			name: "ok assigned to an int",
+			src:  shapes + "func main() {\n\tvar s shape = square{}\n\tvar q square\n\tn := 0\n\tq, n = s.(square)\n}\n",
+			err:  "unable to assign value of type bool to value of type int",
+		},
+		{
+			name: "too many variables",
+			src:  shapes + "func main() {\n\tvar s shape = square{}\n\tq, ok, n := s.(square)\n}\n",
+			err:  "assignment mismatch: 3 variables but 1 value",
+		},
+	})
+}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"go/constant"
 	"go/token"
 	"main/inspector"
 	"main/lexer"
@@ -27,19 +28,28 @@ const (
 	kindUntypedInt // internal type for integer constants
 	KindFloat32
 	KindFloat64
+	kindUntypedFloat // internal type for floating-point constants
 	numeric_end
 
 	KindBool
 	KindSlice
+	KindArray
 	KindString
 	KindStruct
 	KindInterface
+	KindEnum
+	KindTuple
+	kindUntypedNil // internal type for nil
 )
 
 func (k Kind) isNumeric() bool {
 	return numeric_start < k && k < numeric_end
 }
 
+func (k Kind) isInteger() bool {
+	return KindInt <= k && k <= kindUntypedInt
+}
+
 type AstError struct {
 	message string
 	node    parser.AstNode
@@ -49,6 +59,10 @@ func (a *AstError) Error() string {
 	return a.message
 }
 
+func (a *AstError) Node() parser.AstNode {
+	return a.node
+}
+
 func (a *AstError) Format(file *token.File) string {
 	start := file.Position(a.node.Start())
 	end := file.Position(a.node.End())
@@ -77,30 +91,45 @@ type Scoped interface {
 
 type Module struct {
 	*Scope
-	Declarations []Declare
-	Exports      []string
+	// The path the module is imported by.
+	Path string
+	// The module's variables (Declare and AssignTuple steps), in the order
+	// they're initialised.
+	Declarations []Step
+	// The names of the module's public declarations.
+	Exports []string
 }
 
 type ConstantValue struct {
-	value any
+	// nil for nil.
+	value constant.Value
 	typ   Type
 }
 
-func (c ConstantValue) Value() any {
-	return c.value
-}
-
 func (c ConstantValue) Type() Type {
 	return c.typ
 }
 
 type Function struct {
-	p       Scoped
-	Scope   *Scope
-	Name    string
-	Args    []*Argument
-	Steps   []Step
+	p     Scoped
+	Scope *Scope
+	Name  string
+	Args  []*Argument
+	Steps []Step
+	// nil if the function doesn't return anything, a *Tuple if it returns more
+	// than one value.
 	Returns Type
+	// The function's named results, which start as their zero values.
+	named []*Variable
+	// `this` for methods; nil for functions.
+	Receiver *Receiver
+	// Whether the method can be called from other modules.
+	Public bool
+	// Whether calling the function can throw an error.  Only known once the
+	// body of every function in the module has been processed.
+	Throws bool
+	// Where the function can throw.
+	throws []throwSite
 }
 
 func (fn *Function) parent() Scoped {
@@ -142,6 +171,8 @@ type Scope struct {
 	inherits    Scoped
 	Identifiers map[string]any
 	Errors      []AstError
+	// The module's top-level declarations; nil for other scopes.
+	decls *declarations
 }
 
 func (s Scope) parent() Scoped {
@@ -158,6 +189,10 @@ func (s *Scope) error(node parser.AstNode, str string, vals ...interface{}) {
 }
 
 func (s *Scope) Lookup(name string) any {
+	if val, ok := s.resolve(name, nil); ok {
+		return val
+	}
+
 	if val, ok := s.Identifiers[name]; ok {
 		return val
 	}
@@ -170,6 +205,10 @@ func (s *Scope) Lookup(name string) any {
 }
 
 func (s *Scope) lookupIdentifier(node parser.IdentifierNode) any {
+	if val, ok := s.resolve(node.Target, node); ok {
+		return val
+	}
+
 	if val, ok := s.Identifiers[node.Target]; ok {
 		return val
 	}
@@ -182,6 +221,10 @@ func (s *Scope) lookupIdentifier(node parser.IdentifierNode) any {
 		return typ
 	}
 
+	if ident, ok := predeclared[node.Target]; ok {
+		return ident
+	}
+
 	s.error(node, "unable to resolve name: %s", inspector.Inspect(node))
 
 	return nil
@@ -219,47 +262,78 @@ func (s *Scope) lookupTyped(node parser.IdentifierNode) Typed {
 	return val
 }
 
-func (s *Scope) getReturnType() Type {
+// The function s is in; nil for the module's scope, ie variable initialisers.
+func (s *Scope) function() *Function {
 	for scope := Scoped(s); scope != nil; scope = scope.parent() {
 		if fn, ok := scope.(*Function); ok {
-			return fn.Returns
+			return fn
 		}
 	}
 
-	// This shouldn't happen, we don't handle `return` outside of functions.
-	panic("unexpected return")
+	return nil
 }
 
 func (s *Scope) assignValue(node parser.AssignmentNode) Step {
-	// tood: maybe handle par
-	value := s.lookupTyped(node.Assignee)
+	target := s.lookupWriteable(node.Assignee)
 
-	if value == nil {
+	if target == nil {
 		return nil
 	}
 
 	if new := s.preEvaluate(node.Value); new != nil {
-		writeable, ok := value.(Writeable)
-
-		if !ok {
-			s.error(node, "unable to assign value to target '%s'", node.Assignee.Target)
-			return nil
-		}
-
-		if !new.Type().AssignableTo(writeable.Type()) {
-			s.error(node.Value, "unable to assign value of type %s to value of type %s", new.Type().Name(), value.Type().Name())
+		if !new.Type().AssignableTo(target.Type()) {
+			s.error(node.Value, "unable to assign value of type %s to value of type %s%s", new.Type().Name(), target.Type().Name(), unassignable(new.Type(), target.Type()))
 			return nil
 		}
 
 		return Assign{
-			Target: node.Assignee.Target,
-			Value:  new,
+			Target: target,
+			Value:  assignedTo(new, target.Type()),
 		}
 	}
 
 	return nil
 }
 
+// Resolves the target of an assignment: a variable, an argument, or a field or
+// element of either.
+func (s *Scope) lookupWriteable(node parser.ValueNode) Writeable {
+	var target Typed
+
+	switch node := node.(type) {
+	case parser.IdentifierNode:
+		target = s.lookupTyped(node)
+	case parser.PropertyAccessNode:
+		target = s.evaluatePropertyAccess(node)
+	case parser.IndexNode:
+		target = s.evaluateIndex(node)
+	default:
+		s.error(node, "unable to assign to a non-identifier")
+		return nil
+	}
+
+	if target == nil {
+		return nil
+	}
+
+	if _, ok := target.(*Receiver); ok {
+		s.error(node, "cannot assign to 'this'")
+		return nil
+	}
+
+	if writeable, ok := target.(Writeable); ok && isAssignable(writeable) {
+		return writeable
+	}
+
+	if index, ok := target.(Index); ok && index.Of.Type().Kind() == KindString {
+		s.error(node, "cannot assign to an element of a string (strings are immutable)")
+		return nil
+	}
+
+	s.error(node, "unable to assign value to target")
+	return nil
+}
+
 type Variable struct {
 	Name         string
 	InitialValue Typed
@@ -282,12 +356,11 @@ func (s *Scope) declareVariable(node parser.VariableDeclarationNode) (dec Declar
 	var (
 		typ Type
 		val Typed
-		err *AstError
 	)
 
 	if node.Type != nil {
 		// TODO: support inline types.
-		if typ = s.lookupType(node.Type.(parser.IdentifierNode)); err != nil {
+		if typ = s.getType(node.Type); typ == nil {
 			return
 		}
 	}
@@ -298,21 +371,15 @@ func (s *Scope) declareVariable(node parser.VariableDeclarationNode) (dec Declar
 		}
 
 		if typ == nil {
-			typ = val.Type()
-
-			if untyped, isUntyped := typ.(untypedInt); isUntyped {
-				if untyped.AssignableTo(genericInt) {
-					typ = genericInt
-				} else if untyped.AssignableTo(genericInt64) {
-					typ = genericInt64
-				} else if untyped.AssignableTo(genericUint64) {
-					typ = genericUint64
-				} else {
-					s.error(node, "idk how this happened lol")
-				}
+			if typ = s.defaultType(node, val.Type()); typ == nil {
+				return
 			}
-		} else if !val.Type().AssignableTo(typ) {
-			s.error(node, "type %s is unassignable to %s", val.Type().Name(), typ.Name())
+		}
+
+		if !val.Type().AssignableTo(typ) {
+			s.error(node, "type %s is unassignable to %s%s", val.Type().Name(), typ.Name(), unassignable(val.Type(), typ))
+		} else {
+			val = assignedTo(val, typ)
 		}
 	}
 
@@ -330,6 +397,30 @@ func (s *Scope) declareVariable(node parser.VariableDeclarationNode) (dec Declar
 	}
 }
 
+// The type of a variable declared by node without a type, which is
+// initialised to a value of typ, ie int for untyped integers.  nil if there
+// isn't one.
+func (s *Scope) defaultType(node parser.AstNode, typ Type) Type {
+	if typ.Kind() == kindUntypedNil {
+		s.error(node, "use of untyped nil in variable declaration")
+		return nil
+	}
+
+	switch typ := typ.(type) {
+	case untypedFloat:
+		return genericFloat64
+	case untypedInt:
+		if !typ.AssignableTo(genericInt) {
+			s.error(node, "constant %s overflows %s", describeValue(typ.value), genericInt.Name())
+			return nil
+		}
+
+		return genericInt
+	}
+
+	return typ
+}
+
 func (s *Scope) declareConstant(node parser.ConstantDeclarationNode) {
 	name := node.Name()
 	if _, ok := s.Identifiers[name]; ok {
@@ -343,7 +434,7 @@ func (s *Scope) declareConstant(node parser.ConstantDeclarationNode) {
 
 	if node.Type != nil {
 		// TODO: support inline types.
-		if typ = s.lookupType(node.Type.(parser.IdentifierNode)); typ == nil {
+		if typ = s.getType(node.Type); typ == nil {
 			return
 		}
 	}
@@ -356,7 +447,7 @@ func (s *Scope) declareConstant(node parser.ConstantDeclarationNode) {
 		if typ == nil {
 			typ = val.Type()
 		} else if !val.Type().AssignableTo(typ) {
-			s.error(node, "not assignable to type '%s': '%s'", typ.Name(), val.Type().Name())
+			s.error(node, "not assignable to type '%s': '%s'%s", typ.Name(), val.Type().Name(), unassignable(val.Type(), typ))
 			return
 		}
 
@@ -366,11 +457,13 @@ func (s *Scope) declareConstant(node parser.ConstantDeclarationNode) {
 		}
 	}
 
-	s.Identifiers[name] = ConstantValue{
-		value: val.(ConstantValue).value,
-		typ:   typ,
+	// Typed constants are checked against their type, ie `const a int8 = 300`.
+	if val = s.constantOfType(node, val.(ConstantValue).value, typ); val == nil {
+		return
 	}
 
+	s.Identifiers[name] = val
+
 	return
 }
 
@@ -386,113 +479,504 @@ func (s *Scope) getType(node parser.TypeNode) Type {
 	switch node := node.(type) {
 	case parser.IdentifierNode:
 		return s.lookupType(node)
+	case parser.PropertyAccessNode:
+		imp := s.importOf(node)
+
+		if imp == nil {
+			s.error(node, "'%s' is not a module", inspector.Inspect(node.PropertyOf))
+			return nil
+		}
+
+		ident := s.lookupExport(imp, node)
+
+		if ident == nil {
+			return nil
+		}
+
+		typ, ok := ident.(Type)
+		if !ok {
+			s.error(node, "expected '%s.%s' to be a type", imp.Path, node.Property.Target)
+			return nil
+		}
+
+		return typ
+	case parser.SlicePrefixNode:
+		elem := s.getType(node.SliceOf)
+
+		if elem == nil {
+			return nil
+		}
 
+		return &Slice{Elem: elem}
+	case parser.ArrayPrefixNode:
+		return s.arrayType(node)
 	default:
 		return nil
 	}
 }
 
 func (s *Scope) getArguments(nodes []parser.ArgumentDeclarationNode) []*Argument {
-	if len(nodes) == 0 {
-		return []*Argument{}
-	}
-
 	args := make([]*Argument, len(nodes))
 
-	var typ Type
+	var (
+		typ      Type
+		typed    bool
+		variadic bool
+	)
 
-	// lookup args in reverse so earlier args will
+	// Arguments without a type have the type of the next argument, ie `a, b
+	// int`, so they're looked up in reverse.
 	for i := len(args) - 1; i >= 0; i-- {
 		node := nodes[i]
 
-		if node.Type == nil && typ == nil {
-			s.error(node, "expected argument '%s' to have a type", node.Name())
-		}
-
-		maybeType := s.getType(node.Type)
+		switch {
+		case node.Type != nil:
+			typ, typed, variadic = s.getType(node.Type), true, node.Variadic
 
-		if maybeType != nil {
-			typ = maybeType
-		} else {
-			if typ == nil {
-				s.error(node, "expected argument '%s' to have a type", node.Name())
+			if typ != nil && variadic {
+				typ = &Slice{Elem: typ}
 			}
+		case !typed:
+			s.error(node, "expected argument '%s' to have a type", node.Name())
+		case variadic:
+			s.error(node, "can only use ... with final argument")
 		}
 
 		args[i] = &Argument{
-			Name: node.Name(),
-			typ:  typ,
+			Name:     node.Name(),
+			typ:      typ,
+			Variadic: node.Variadic,
 		}
-
 	}
 
 	return args
 }
 
 func (s *Scope) handleBlock(block parser.BlockNode) (steps []Step) {
-	for _, step := range block.Steps {
-		switch node := step.(type) {
-		case parser.VariableDeclarationNode:
-			steps = append(steps, s.declareVariable(node))
-
-		case parser.ConstantDeclarationNode:
-			s.declareConstant(node)
-		case parser.AssignmentNode:
-			steps = append(steps, s.assignValue(node))
-		case parser.IfNode:
-			steps = append(steps, s.handleIf(node))
-		case parser.CallNode:
-			steps = append(steps, s.handleCall(node))
-		case parser.ReturnNode:
-			steps = append(steps, s.handleReturn(node))
+	for _, node := range block.Steps {
+		if step := s.handleStep(node); step != nil {
+			steps = append(steps, step)
+		}
+
+		// Anything after these is unreachable.
+		switch node.(type) {
+		case parser.ReturnNode, parser.ThrowNode, parser.BranchNode:
 			return
-		default:
-			panic(fmt.Errorf("unhandled node: %s", reflect.TypeOf(node).Name()))
 		}
 	}
 
 	return
 }
 
-func (s *Scope) handleReturn(node parser.ReturnNode) Step {
-	typ := s.getReturnType()
-
-	if typ == nil && node.Value != nil {
-		s.error(node, "unexpected return value; function returns nothing")
+func (s *Scope) handleStep(step parser.StepNode) Step {
+	switch node := step.(type) {
+	case parser.VariableDeclarationNode:
+		return s.declareVariable(node)
+	case parser.ConstantDeclarationNode:
+		s.declareConstant(node)
+	case parser.TupleDeclarationNode:
+		return s.declareTuple(node)
+	case parser.AssignmentNode:
+		return s.assignValue(node)
+	case parser.TupleAssignmentNode:
+		return s.assignTuple(node)
+	case parser.SuffixUnaryOperationNode:
+		return s.handleIncrement(node)
+	case parser.IfNode:
+		return s.handleIf(node)
+	case parser.ForNode:
+		return s.handleFor(node)
+	case parser.SwitchNode:
+		return s.handleSwitch(node)
+	case parser.BranchNode:
+		return s.handleBranch(node)
+	case parser.CallNode:
+		if call := s.handleCall(node); call.Target != nil {
+			return call
+		}
 		return nil
+	case parser.ReturnNode:
+		return s.handleReturn(node)
+	case parser.ThrowNode:
+		return s.handleThrow(node)
+	case parser.StartNode:
+		return s.handleStart(node)
+	case parser.InvalidNode:
+		// Already reported by the parser.
+	default:
+		panic(fmt.Errorf("unhandled node: %s", reflect.TypeOf(node).Name()))
 	}
 
-	val := s.preEvaluate(node.Value)
+	return nil
+}
+
+// `a++` and `a--` are handled as `a = a + 1` and `a = a - 1`.
+func (s *Scope) handleIncrement(node parser.SuffixUnaryOperationNode) Step {
+	val := s.evaluateUnarySuffixExpression(node)
 
 	if val == nil {
 		return nil
 	}
 
-	if !val.Type().AssignableTo(typ) {
-		s.error(node, "invalid return: expected value of type '%s'; received value of type '%s';", typ.Name(), val.Type().Name())
+	op := lexer.ADD
+	if node.Operator == lexer.DECR {
+		op = lexer.SUB
+	}
+
+	target := val.(SuffixUnaryOperation).Target
+
+	return Assign{
+		Target: target,
+		Value: BinaryOperation{
+			Left:     target,
+			Right:    untyped(constant.MakeInt64(1)),
+			Operator: op,
+		},
+	}
+}
+
+// Whether a value of typ can be used as a condition, which has to be a bool.
+func isCondition(typ Type) bool {
+	return typ != nil && typ.Kind() == KindBool
+}
+
+func (s *Scope) handleFor(node parser.ForNode) Step {
+	loop := &Loop{p: s}
+	loop.Scope = newScope(loop)
+
+	if node.Init != nil {
+		loop.Init = loop.Scope.handleStep(node.Init)
+	}
+
+	if node.Condition != nil {
+		loop.Condition = loop.Scope.preEvaluate(node.Condition)
+
+		if loop.Condition == nil {
+			return nil
+		}
+
+		if !isCondition(loop.Condition.Type()) {
+			s.error(node.Condition, "invalid loop condition: expected a bool; received value of type '%s'", loop.Condition.Type().Name())
+			return nil
+		}
+
+		if val, ok := loop.Condition.(ConstantValue); ok {
+			// The loop never runs; only its init statement is reachable.
+			if val.isZero() {
+				block := Block{Scope: loop.Scope}
+
+				if loop.Init != nil {
+					block.Steps = []Step{loop.Init}
+				}
+
+				return block
+			}
+
+			loop.Condition = nil
+		}
+	}
+
+	loop.Block = handleChildBlock(loop.Scope, node.Block)
+
+	if node.Post != nil {
+		loop.Post = loop.Scope.handleStep(node.Post)
+	}
+
+	return loop
+}
+
+func (s *Scope) handleBranch(node parser.BranchNode) Step {
+	switch node.Token {
+	case lexer.FALLTHROUGH:
+		// Fallthroughs at the end of a case are handled by handleSwitch.
+		s.error(node, "fallthrough statement out of place")
+		return nil
+	case lexer.BREAK:
+		if !s.inLoop(true) {
+			s.error(node, "break is not in a loop or switch")
+			return nil
+		}
+
+		return Break{}
+	}
+
+	if !s.inLoop(false) {
+		s.error(node, "%s is not in a loop", node.Token)
+		return nil
+	}
+
+	return Continue{}
+}
+
+// Whether s is in a loop, or a switch if switches is true.
+func (s *Scope) inLoop(switches bool) bool {
+	for scope := Scoped(s); scope != nil; scope = scope.parent() {
+		switch scope.(type) {
+		case *Loop:
+			return true
+		case *Switch:
+			if switches {
+				return true
+			}
+		case *Function:
+			return false
+		}
+	}
+
+	return false
+}
+
+// The types of a function's results, ie `int, string` for `(int, string)`.
+func resultTypes(typ Type) []Type {
+	switch typ := typ.(type) {
+	case nil:
+		return nil
+	case *Tuple:
+		return typ.Types
+	}
+
+	return []Type{typ}
+}
+
+// The names of the types of vals, ie `(int, string)`.
+func typesOf(vals []Typed) string {
+	tuple := &Tuple{Types: make([]Type, len(vals))}
+
+	for i, val := range vals {
+		tuple.Types[i] = val.Type()
+	}
+
+	return tuple.Name()
+}
+
+// Invalid returns still end the function, so they're kept as an empty Return
+// rather than reporting a missing return as well.
+func (s *Scope) handleReturn(node parser.ReturnNode) Step {
+	fn := s.function()
+	want := resultTypes(fn.Returns)
+
+	if len(node.Values) == 0 {
+		switch {
+		case fn.Returns == nil:
+		case len(fn.named) > 0:
+			// A bare return returns the named results.
+			vals := make([]Typed, len(fn.named))
+
+			for i, v := range fn.named {
+				vals[i] = v
+			}
+
+			return Return{Value: results(vals, fn.Returns)}
+		default:
+			s.error(node, "not enough return values: have (), want %s", (&Tuple{Types: want}).Name())
+		}
+
+		return Return{}
+	}
+
+	if fn.Returns == nil {
+		s.error(node, "unexpected return value; function returns nothing")
+		return Return{}
 	}
 
-	return Return{Value: val}
+	// Same as go, `return f()` returns every result of f.
+	if call, ok := node.Values[0].(parser.CallNode); ok && len(node.Values) == 1 && len(want) > 1 && s.typeNamed(call.Callee) == nil {
+		val := s.handleCall(call)
+
+		if val.Target == nil {
+			return Return{}
+		}
+
+		switch typ := val.Type(); {
+		case typ == nil:
+			s.error(call, "%s() (no value) used as value", val.Target.Name)
+			return Return{}
+		case !identical(typ, fn.Returns):
+			s.error(node, "invalid return: expected value of type '%s'; received value of type '%s'", fn.Returns.Name(), typ.Name())
+			return Return{}
+		}
+
+		return Return{Value: val}
+	}
+
+	vals := make([]Typed, len(node.Values))
+
+	for i, value := range node.Values {
+		if vals[i] = s.preEvaluate(value); vals[i] == nil {
+			return Return{}
+		}
+	}
+
+	if len(vals) != len(want) {
+		problem := "not enough"
+		if len(vals) > len(want) {
+			problem = "too many"
+		}
+
+		s.error(node, "%s return values: have %s, want %s", problem, typesOf(vals), (&Tuple{Types: want}).Name())
+		return Return{}
+	}
+
+	for i, val := range vals {
+		if !val.Type().AssignableTo(want[i]) {
+			s.error(node.Values[i], "invalid return: expected value of type '%s'; received value of type '%s'%s", want[i].Name(), val.Type().Name(), unassignable(val.Type(), want[i]))
+			return Return{}
+		}
+
+		vals[i] = assignedTo(val, want[i])
+	}
+
+	return Return{Value: results(vals, fn.Returns)}
+}
+
+// The value returned by `return vals...` from a function returning typ.
+func results(vals []Typed, typ Type) Typed {
+	if tuple, ok := typ.(*Tuple); ok {
+		return TupleValue{Values: vals, typ: tuple}
+	}
+
+	return vals[0]
 }
 
 func (s *Scope) handleTopLevelFunction(node parser.FunctionNode) *Function {
 	fn := &Function{p: s}
-	if node.Returns.Target != "" {
-		fn.Returns = s.lookupType(node.Returns)
-	}
 	fn.Scope = newScope(fn)
 
 	defer fn.Scope.removeConstants()
 
 	fn.Args = s.getArguments(node.Arguments.Arguments)
+	results := s.getArguments(node.Results)
+	fn.Returns = resultsType(results)
 
-	for _, arg := range fn.Args {
-		fn.Scope.Identifiers[arg.Name] = arg
+	declare := func(node parser.AstNode, name string, val any) {
+		if name == "_" {
+			return
+		}
+
+		if _, ok := fn.Scope.Identifiers[name]; ok {
+			s.error(node, "duplicate argument '%s'", name)
+			return
+		}
+
+		fn.Scope.Identifiers[name] = val
+	}
+
+	for i, arg := range fn.Args {
+		declare(node.Arguments.Arguments[i], arg.Name, arg)
+	}
+
+	for i, result := range results {
+		if result.Name == "" {
+			continue
+		}
+
+		v := &Variable{Name: result.Name, typ: result.Type()}
+		fn.named = append(fn.named, v)
+		declare(node.Results[i], result.Name, v)
 	}
 
 	return fn
 }
 
+// Processes the body of fn, which starts by declaring its named results.
+func (fn *Function) handleBody(block parser.BlockNode) {
+	fn.Steps = nil
+
+	for _, v := range fn.named {
+		fn.Steps = append(fn.Steps, Declare{Name: v.Name, Variable: v})
+	}
+
+	fn.Steps = append(fn.Steps, fn.Scope.handleBlock(block)...)
+
+	if fn.Returns != nil && !terminates(fn.Steps) {
+		fn.Scope.error(closingBrace(block.End()-1), "missing return")
+	}
+}
+
+// The `}` at the end of a block, for errors about the end of it.
+type closingBrace token.Pos
+
+func (c closingBrace) Start() token.Pos {
+	return token.Pos(c)
+}
+
+func (c closingBrace) End() token.Pos {
+	return token.Pos(c) + 1
+}
+
+// Whether steps can't finish normally, ie end with a return.  Same as go,
+// conditions aren't evaluated, so `if` statements terminate if every branch
+// does, and loops if they don't have a condition or a `break`.
+func terminates(steps []Step) bool {
+	if len(steps) == 0 {
+		return false
+	}
+
+	switch step := steps[len(steps)-1].(type) {
+	case Return, Throw:
+		return true
+	case Block:
+		return terminates(step.Steps)
+	case If:
+		if step.Else == nil || !terminates(step.Then.Steps) || !terminates(step.Else.Steps) {
+			return false
+		}
+
+		for _, elif := range step.ElseIf {
+			if !terminates(elif.Then.Steps) {
+				return false
+			}
+		}
+
+		return true
+	case *Loop:
+		return step.Condition == nil && !breaks(step.Block.Steps)
+	case *Switch:
+		// Every case has to terminate (or fall through to one which does), and
+		// there has to be a default so one of them runs.
+		hasDefault := false
+
+		for _, c := range step.Cases {
+			if breaks(c.Block.Steps) || (!c.Fallthrough && !terminates(c.Block.Steps)) {
+				return false
+			}
+
+			hasDefault = hasDefault || c.Values == nil
+		}
+
+		return hasDefault
+	}
+
+	return false
+}
+
+// Whether steps break out of the loop or switch they're in.  Breaks in nested
+// loops and switches don't count, they end the nested one.
+func breaks(steps []Step) bool {
+	for _, step := range steps {
+		switch step := step.(type) {
+		case Break:
+			return true
+		case Block:
+			if breaks(step.Steps) {
+				return true
+			}
+		case If:
+			if breaks(step.Then.Steps) || (step.Else != nil && breaks(step.Else.Steps)) {
+				return true
+			}
+
+			for _, elif := range step.ElseIf {
+				if breaks(elif.Then.Steps) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
 // TODO: do this better.
 func (s *Scope) removeConstants() {
 	consts := []string{}
@@ -518,12 +1002,40 @@ func handleChildBlock(s Scoped, node parser.BlockNode) Block {
 }
 
 func (s *Scope) handleIf(node parser.IfNode) Step {
+	if node.Init != nil {
+		// The init statement's variables are in scope for every branch, so the
+		// whole statement lives in a scope of its own.
+		child := newScope(s)
+		block := Block{Scope: child}
+
+		if init := child.handleStep(node.Init); init != nil {
+			block.Steps = append(block.Steps, init)
+		}
+
+		node.Init = nil
+
+		if stp := child.handleIf(node); stp != nil {
+			block.Steps = append(block.Steps, stp)
+		}
+
+		return block
+	}
+
 	val := s.preEvaluate(node.Condition)
 
+	if val == nil {
+		return nil
+	}
+
+	if !isCondition(val.Type()) {
+		s.error(node.Condition, "invalid if condition: expected a bool; received value of type '%s'", val.Type().Name())
+		return nil
+	}
+
 	if val, ok := val.(ConstantValue); ok {
 		scope := newScope(s)
 
-		if !reflect.ValueOf(val.value).IsZero() {
+		if !val.isZero() {
 			return handleChildBlock(s, node.Then)
 		}
 
@@ -552,14 +1064,31 @@ func (s *Scope) handleIf(node parser.IfNode) Step {
 	stp := If{
 		Condition: val,
 		Then:      handleChildBlock(s, node.Then),
-		ElseIf:    make([]If, len(node.ElseIf)),
 	}
 
 	for i, elif := range node.ElseIf {
-		stp.ElseIf[i] = If{
-			Condition: s.preEvaluate(elif.Condition),
-			Then:      handleChildBlock(s, elif.Then),
+		if elif.Init != nil {
+			// The rest of the chain is in the scope of the init statement, so
+			// it's nested in the else block.
+			elif.ElseIf = node.ElseIf[i+1:]
+			elif.Else = node.Else
+
+			bl := s.handleIf(elif).(Block)
+			stp.Else = &bl
+
+			return stp
 		}
+
+		cond := s.preEvaluate(elif.Condition)
+
+		if cond != nil && !isCondition(cond.Type()) {
+			s.error(elif.Condition, "invalid if condition: expected a bool; received value of type '%s'", cond.Type().Name())
+		}
+
+		stp.ElseIf = append(stp.ElseIf, If{
+			Condition: cond,
+			Then:      handleChildBlock(s, elif.Then),
+		})
 	}
 
 	if node.Else != nil {
@@ -570,61 +1099,78 @@ func (s *Scope) handleIf(node parser.IfNode) Step {
 	return stp
 }
 
-func ProcessModule(ast parser.ModuleNode) (mod Module) {
-	// TODO: consider unordered declarations.
-	// This can be done by lazy-loading everything then in lookupValue evaluate
-	// the variable / constant with a stack to detect recursive dependencies.
-	//
-	// e.g.:
-	// ```
-	// const a = b;
-	// const b = 32;
-	// ````
-
+// Processes a module; the modules it imports are looked up by their path in
+// imports, and must have been processed already.
+func ProcessModule(ast parser.ModuleNode, imports map[string]*Module) (mod Module) {
+	// Constants are kept in the module's scope so other modules can use them.
 	scope := newScope(nil)
-	defer scope.removeConstants()
+	scope.decls = newDeclarations()
 	mod.Scope = scope
 
 	for _, node := range ast.Nodes {
+		isPublic := false
+
 		if pub, ok := node.(parser.PublicNode); ok {
-			mod.Exports = append(mod.Exports, pub.Node.(parser.DeclarationNode).Name())
-			node = pub.Node
+			// Methods aren't members of the module; they're accessed through
+			// their type, so are only marked as public.
+			switch pub := pub.Node.(type) {
+			case parser.MethodDeclarationNode:
+			case parser.TupleDeclarationNode:
+				for _, name := range pub.Names {
+					mod.Exports = append(mod.Exports, name.Target)
+				}
+			default:
+				mod.Exports = append(mod.Exports, pub.(parser.NamedNode).Name())
+			}
+			node, isPublic = pub.Node, true
 		}
 
 		switch node := node.(type) {
-		case parser.VariableDeclarationNode:
-
-			mod.Declarations = append(mod.Declarations, scope.declareVariable(node))
-		case parser.ConstantDeclarationNode:
-			scope.declareConstant(node)
-		case parser.ModuleFunctionDeclarationNode:
-			// TODO: assert name doesn't already exist.
-			fn := scope.handleTopLevelFunction(node.Body)
-			fn.Name = node.Name()
-			scope.Identifiers[node.Name()] = fn
-
-			// Defer loading of the function's steps until we read every function
-			defer func() { fn.Steps = fn.Scope.handleBlock(node.Body.Block) }()
-
+		case parser.ImportDeclarationNode:
+			scope.declareImport(node, imports)
+		case parser.VariableDeclarationNode, parser.TupleDeclarationNode, parser.ConstantDeclarationNode, parser.StructDeclarationNode,
+			parser.EnumDeclarationNode, parser.InterfaceDeclarationNode, parser.ModuleFunctionDeclarationNode,
+			parser.MethodDeclarationNode:
+			// Resolved once everything is declared, so declarations can refer
+			// to ones after them.
+			scope.addDeclaration(node.(parser.NamedNode), isPublic)
+		case parser.InvalidNode:
+			// Already reported by the parser.
 		default:
 			panic(fmt.Errorf("unhandled node: %s", reflect.TypeOf(node).Name()))
 		}
 	}
 
+	scope.resolveAll()
+	mod.Declarations = scope.decls.variables
+
 	return
 }
 
 func (s *Scope) handleInlineFunction(node parser.FunctionNode) (fn *Function) {
-	fn.Scope = newScope(fn)
-	scope := newScope(fn)
-	fn.Args = scope.getArguments(node.Arguments.Arguments)
-	fn.Steps = scope.handleBlock(node.Block)
+	fn = s.handleTopLevelFunction(node)
+	fn.handleBody(node.Block)
 
 	return
 }
 
+// Evaluates a call whose error (if it throws one) is thrown to the caller.
 func (s *Scope) handleCall(node parser.CallNode) Call {
-	var fn *Function
+	call := s.evaluateCall(node)
+
+	if call.Target != nil {
+		s.addThrowSite(throwSite{node: node, call: call.Target})
+	}
+
+	return call
+}
+
+func (s *Scope) evaluateCall(node parser.CallNode) Call {
+	var (
+		fn       *Function
+		receiver Typed
+	)
+
 	switch callee := node.Callee.(type) {
 	case parser.IdentifierNode:
 		i := s.lookupIdentifier(callee)
@@ -637,29 +1183,89 @@ func (s *Scope) handleCall(node parser.CallNode) Call {
 		fn = ident
 	case parser.FunctionNode:
 		fn = s.handleInlineFunction(callee)
+	case parser.PropertyAccessNode:
+		if imp := s.importOf(callee); imp != nil {
+			ident := s.lookupExport(imp, callee)
+
+			if ident == nil {
+				return Call{}
+			}
+
+			if fn, _ = ident.(*Function); fn == nil {
+				s.error(callee, "not a function")
+				return Call{}
+			}
+
+			break
+		}
+
+		if fn, receiver = s.lookupMethod(callee); fn == nil {
+			return Call{}
+		}
 	default:
-		panic("unexpected")
+		// ie `(a + b)(1)`.
+		s.error(node.Callee, "cannot call non-function")
+		return Call{}
 	}
 
-	// TODO: if variadic functions are added, this logic needs to change.
-	if len(node.Arguments) != len(fn.Args) {
-		s.error(node, "incorrect number of arguments for function; expected %d", len(fn.Args))
+	variadic := len(fn.Args) > 0 && fn.Args[len(fn.Args)-1].Variadic
+	// The arguments which are passed one value each.
+	fixed := len(fn.Args)
+
+	switch {
+	case node.Spread && !variadic:
+		s.error(node, "cannot use ... in call to non-variadic function")
 		return Call{}
+	case !variadic || node.Spread:
+		if len(node.Arguments) != len(fn.Args) {
+			s.error(node, "incorrect number of arguments for function; expected %d", len(fn.Args))
+			return Call{}
+		}
+	default:
+		// The variadic argument takes the rest of the values.
+		if fixed--; len(node.Arguments) < fixed {
+			s.error(node, "incorrect number of arguments for function; expected at least %d", fixed)
+			return Call{}
+		}
 	}
 
 	step := Call{
 		Target:    fn,
+		Receiver:  receiver,
 		Arguments: make([]Typed, len(fn.Args)),
 	}
 
+	vals := make([]Typed, len(node.Arguments))
+
 	for i, arg := range node.Arguments {
-		val := s.preEvaluate(arg)
+		if vals[i] = s.preEvaluate(arg); vals[i] == nil {
+			return Call{}
+		}
+
+		var typ Type
 
-		if !val.Type().AssignableTo(fn.Args[i].Type()) {
-			s.error(node, "invalid argument type '%s'; expected '%s'", val.Type().Name(), fn.Args[i].Type().Name())
+		if i < fixed {
+			typ = fn.Args[i].Type()
+		} else if slice, ok := fn.Args[fixed].Type().(*Slice); ok {
+			typ = slice.Elem
+		} else {
+			// The argument's type failed to resolve.
+			return Call{}
 		}
 
-		step.Arguments[i] = val
+		if !vals[i].Type().AssignableTo(typ) {
+			s.error(arg, "invalid argument type '%s'; expected '%s'%s", vals[i].Type().Name(), typ.Name(), unassignable(vals[i].Type(), typ))
+			continue
+		}
+
+		vals[i] = assignedTo(vals[i], typ)
+	}
+
+	copy(step.Arguments, vals[:fixed])
+
+	if fixed < len(fn.Args) {
+		slice := fn.Args[fixed].Type().(*Slice)
+		step.Arguments[fixed] = SliceValue{Values: vals[fixed:], typ: slice}
 	}
 
 	return step
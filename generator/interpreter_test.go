@@ -0,0 +1,78 @@
+package generator
+
+import "testing"
+
+func TestCalls(t *testing.T) {
+	runSourceTests(t, []sourceTest{
+		{
+			name: "arguments",
+			src:  "func f(a int, b string) int {\n\treturn a\n}\n\nfunc main() {\n\tf(1, \"a\")\n}\n",
+		},
+		{
+			name: "variadic arguments",
+			src:  "func f(a int, b ...int) int {\n\treturn a\n}\n\nfunc main() {\n\tf(1)\n\tf(1, 2, 3)\n}\n",
+		},
+		{
+			name: "nil argument",
+			src:  "func f(a int) {\n}\n\nfunc main() {\n\tf(nil)\n}\n",
+			err:  "invalid argument type 'untyped nil'; expected 'int'",
+		},
+		{
+			name: "nil method argument",
+			src:  "struct T {\n\tn int\n}\n\nfunc T.m(a int) {\n}\n\nfunc main() {\n\tT{}.m(nil)\n}\n",
+			err:  "invalid argument type 'untyped nil'; expected 'int'",
+		},
+		{
+			name: "wrong number of arguments",
+			src:  "func f(a int) {\n}\n\nfunc main() {\n\tf(1, 2)\n}\n",
+			err:  "incorrect number of arguments for function; expected 1",
+		},
+		{
+			name: "call of a variable",
+			src:  "func main() {\n\ta := 1\n\ta(1)\n}\n",
+			err:  "not a function",
+		},
+		{
+			name: "call of an operation",
+			src:  "func main() {\n\ta := 1\n\tb := (a + a)(1)\n}\n",
+			err:  "cannot call non-function",
+		},
+		{
+			name: "call of an index",
+			src:  "func main() {\n\tarr := [1]int{1}\n\tb := arr[0](1)\n}\n",
+			err:  "cannot call non-function",
+		},
+		{
+			name: "call of a literal",
+			src:  "func main() {\n\tb := 1.(int)\n}\n",
+			err:  "cannot call non-function",
+		},
+	})
+}
+
+func TestDefaultType(t *testing.T) {
+	runSourceTests(t, []sourceTest{
+		{
+			name: "int",
+			src:  "func main() {\n\ta := 2147483647\n\tvar b int = a\n}\n",
+		},
+		{
+			name: "float",
+			src:  "func main() {\n\ta := 1.5\n\tvar b float64 = a\n}\n",
+		},
+		{
+			name: "too big for int",
+			src:  "func main() {\n\ta := 2147483648\n}\n",
+			err:  "constant 2147483648 overflows int",
+		},
+		{
+			name: "too big for uint64",
+			src:  "var a = 1 << 64\n",
+			err:  "overflows int",
+		},
+		{
+			name: "too big for int, with a type",
+			src:  "func main() {\n\tvar a int64 = 2147483648\n}\n",
+		},
+	})
+}
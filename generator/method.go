@@ -0,0 +1,130 @@
+package generator
+
+import "main/parser"
+
+// The receiver of a method, `this`.
+type Receiver struct {
+	typ Type
+	// Whether this refers to the value the method was called on (`func *T.m()`)
+	// rather than a copy of it (`func T.m()`).
+	Ptr bool
+}
+
+func (r *Receiver) Type() Type {
+	return r.typ
+}
+
+func (*Receiver) isWriteable() {}
+
+func (s *Scope) declareMethod(node parser.MethodDeclarationNode) *Function {
+	ident := s.Lookup(node.MethodOf)
+	if ident == nil {
+		if typ, ok := Generics[node.MethodOf]; ok {
+			ident = typ
+		}
+	}
+
+	var (
+		typ     Type
+		methods *[]*Function
+		name    = node.Name()
+	)
+
+	switch ident := ident.(type) {
+	case *Struct:
+		for _, field := range ident.Fields {
+			if field.Name == name {
+				s.error(node, "field and method with the same name '%s'", name)
+				return nil
+			}
+		}
+
+		typ, methods = ident, &ident.Methods
+	case *Enum:
+		typ, methods = ident, &ident.Methods
+	case nil:
+		s.error(node, "unable to resolve name: %s", node.MethodOf)
+		return nil
+	case Type:
+		s.error(node, "cannot define methods on type '%s'", ident.Name())
+		return nil
+	default:
+		s.error(node, "expected '%s' to be a type", node.MethodOf)
+		return nil
+	}
+
+	for _, m := range *methods {
+		if m.Name == name {
+			s.error(node, "method '%s.%s' is already declared", typ.Name(), name)
+			return nil
+		}
+	}
+
+	fn := s.handleTopLevelFunction(node.Body)
+	fn.Name = name
+	fn.Receiver = &Receiver{typ: typ, Ptr: node.IsPtrReceiver}
+
+	if _, ok := fn.Scope.Identifiers["this"]; ok {
+		s.error(node, "duplicate argument 'this'")
+	}
+	fn.Scope.Identifiers["this"] = fn.Receiver
+
+	*methods = append(*methods, fn)
+
+	return fn
+}
+
+// Resolves the method called by `a.m()`, along with the value it's called on.
+func (s *Scope) lookupMethod(node parser.PropertyAccessNode) (*Function, Typed) {
+	of := s.preEvaluate(node.PropertyOf)
+
+	if of == nil {
+		return nil, nil
+	}
+
+	var (
+		name      = node.Property.Target
+		path      []int
+		method    *Function
+		ambiguous bool
+	)
+
+	switch typ := of.Type().(type) {
+	case *Struct:
+		path, method, ambiguous = typ.lookupSelector(name)
+	case *Enum:
+		method = typ.method(name)
+	case *Interface:
+		method = typ.method(name)
+	}
+
+	if ambiguous {
+		s.error(node.Property, "ambiguous selector '%s'", name)
+		return nil, nil
+	}
+
+	if method == nil {
+		if path != nil {
+			s.error(node.Property, "'%s' is a field, not a method", name)
+		} else {
+			s.error(node.Property, "type '%s' has no method '%s'", of.Type().Name(), name)
+		}
+		return nil, nil
+	}
+
+	// The methods of interfaces are as public as the interface itself.
+	if _, ok := of.Type().(*Interface); !ok && !method.Public && moduleScope(method) != moduleScope(s) {
+		s.error(node.Property, "cannot call private method '%s' of type '%s' from another module", name, of.Type().Name())
+		return nil, nil
+	}
+
+	receiver := accessPath(of, path)
+
+	// Same as go, there has to be something for `this` to point to.
+	if method.Receiver.Ptr && !isAssignable(receiver) {
+		s.error(node, "cannot call pointer method '%s' on a value which can't be assigned to", name)
+		return nil, nil
+	}
+
+	return method, receiver
+}
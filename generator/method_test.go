@@ -0,0 +1,58 @@
+package generator
+
+import "testing"
+
+const counter = "struct T {\n\tn int\n}\n\nfunc T.get() int {\n\treturn this.n\n}\n\nfunc *T.set(n int) {\n\tthis.n = n\n}\n\n"
+
+func TestMethods(t *testing.T) {
+	runSourceTests(t, []sourceTest{
+		{
+			name: "value and pointer receivers",
+			src:  counter + "func main() {\n\tt := T{}\n\tt.set(1)\n\tvar n int = t.get()\n}\n",
+		},
+		{
+			name: "promoted",
+			src:  counter + "struct O {\n\tT\n}\n\nfunc main() {\n\to := O{}\n\to.set(1)\n\tvar n int = o.get()\n}\n",
+		},
+		{
+			name: "result type",
+			src:  counter + "func main() {\n\tvar s string = T{}.get()\n}\n",
+			err:  "type int is unassignable to string",
+		},
+		{
+			name: "pointer method of a value which can't be assigned to",
+			src:  counter + "func main() {\n\tT{}.set(1)\n}\n",
+			err:  "cannot call pointer method 'set' on a value which can't be assigned to",
+		},
+		{
+			name: "missing method",
+			src:  counter + "func main() {\n\tT{}.nope()\n}\n",
+			err:  "type 'T' has no method 'nope'",
+		},
+		{
+			name: "redeclared",
+			src:  counter + "func T.get() int {\n\treturn 1\n}\n",
+			err:  "method 'T.get' is already declared",
+		},
+		{
+			name: "same name as a field",
+			src:  counter + "func T.n() {\n}\n",
+			err:  "field and method with the same name 'n'",
+		},
+		{
+			name: "undeclared type",
+			src:  "func U.m() {\n}\n",
+			err:  "unable to resolve name: U",
+		},
+		{
+			name: "builtin type",
+			src:  "func int.m() {\n}\n",
+			err:  "cannot define methods on type 'int'",
+		},
+		{
+			name: "this outside a method",
+			src:  "func main() {\n\tthis.n = 1\n}\n",
+			err:  "unable to resolve name: this",
+		},
+	})
+}
@@ -0,0 +1,107 @@
+package generator
+
+import "main/parser"
+
+// A module imported by another, ie the `factorio` of `factorio.Lamp`.
+type Import struct {
+	// The path the module was imported by.
+	Path string
+	*Module
+}
+
+func (m *Module) isExported(name string) bool {
+	for _, export := range m.Exports {
+		if export == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The scope of the module s belongs to.
+func moduleScope(s Scoped) Scoped {
+	for s.parent() != nil {
+		s = s.parent()
+	}
+
+	return s
+}
+
+// The import node is qualified by, ie `factorio` for `factorio.Lamp`.  nil if
+// it isn't qualified by an import.
+func (s *Scope) importOf(node parser.PropertyAccessNode) *Import {
+	ident, ok := node.PropertyOf.(parser.IdentifierNode)
+
+	if !ok {
+		return nil
+	}
+
+	imp, _ := s.Lookup(ident.Target).(*Import)
+	return imp
+}
+
+// The public identifier of imp named by node, or nil if it doesn't exist or
+// isn't public.
+func (imp *Import) exported(node parser.PropertyAccessNode) any {
+	if !imp.isExported(node.Property.Target) {
+		return nil
+	}
+
+	return imp.Identifiers[node.Property.Target]
+}
+
+// Same as exported, but reports the identifier not existing or being private.
+func (s *Scope) lookupExport(imp *Import, node parser.PropertyAccessNode) any {
+	name := node.Property.Target
+
+	ident, ok := imp.Identifiers[name]
+
+	if !ok {
+		s.error(node, "module '%s' has no member '%s'", imp.Path, name)
+		return nil
+	}
+
+	if !imp.isExported(name) {
+		s.error(node, "cannot refer to private name '%s' of module '%s'", name, imp.Path)
+		return nil
+	}
+
+	return ident
+}
+
+// Resolves `a.b`, where a is an import and b is a variable or constant.
+func (s *Scope) evaluateQualified(imp *Import, node parser.PropertyAccessNode) Typed {
+	ident := s.lookupExport(imp, node)
+
+	if ident == nil {
+		return nil
+	}
+
+	val, ok := ident.(Typed)
+
+	if !ok {
+		s.error(node, "expected '%s.%s' to be a type or variable", imp.Path, node.Property.Target)
+		return nil
+	}
+
+	return val
+}
+
+func (s *Scope) declareImport(node parser.ImportDeclarationNode, imports map[string]*Module) {
+	name := node.Name()
+
+	if _, ok := s.Identifiers[name]; ok {
+		s.error(node, "cannot redeclare identifier '%s'", name)
+		return
+	}
+
+	mod, ok := imports[node.Path]
+
+	if !ok {
+		// Already reported by the loader.
+		return
+	}
+
+	s.Identifiers[name] = &Import{Path: node.Path, Module: mod}
+}
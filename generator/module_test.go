@@ -0,0 +1,60 @@
+package generator
+
+import "testing"
+
+const lib = "public var N = 1\nvar hidden = 2\n\npublic struct Point {\n\tX int\n}\n\npublic func Point.Len() int {\n\treturn this.X\n}\n\nfunc Point.secret() int {\n\treturn 1\n}\n\npublic func Add(a, b int) int {\n\treturn a + b\n}\n\nfunc sub(a, b int) int {\n\treturn a - b\n}\n"
+
+func TestImports(t *testing.T) {
+	for _, test := range []sourceTest{
+		{
+			name: "public function and variable",
+			src:  "import \"lib\"\n\nfunc main() {\n\tvar a int = lib.Add(lib.N, 2)\n}\n",
+		},
+		{
+			name: "public type and method",
+			src:  "import \"lib\"\n\nfunc main() {\n\tvar p lib.Point = lib.Point{X: 1}\n\tvar n int = p.Len() + p.X\n}\n",
+		},
+		{
+			name: "types of public declarations",
+			src:  "import \"lib\"\n\nfunc main() {\n\tvar a string = lib.Add(1, 2)\n}\n",
+			err:  "type int is unassignable to string",
+		},
+		{
+			name: "private function",
+			src:  "import \"lib\"\n\nfunc main() {\n\tlib.sub(1, 2)\n}\n",
+			err:  "cannot refer to private name 'sub' of module 'lib'",
+		},
+		{
+			name: "private variable",
+			src:  "import \"lib\"\n\nfunc main() {\n\ta := lib.hidden\n}\n",
+			err:  "cannot refer to private name 'hidden' of module 'lib'",
+		},
+		{
+			name: "private method",
+			src:  "import \"lib\"\n\nfunc main() {\n\tp := lib.Point{X: 1}\n\tp.secret()\n}\n",
+			err:  "cannot call private method 'secret' of type 'Point' from another module",
+		},
+		{
+			name: "missing member",
+			src:  "import \"lib\"\n\nfunc main() {\n\ta := lib.nope\n}\n",
+			err:  "module 'lib' has no member 'nope'",
+		},
+		{
+			name: "missing type",
+			src:  "import \"lib\"\n\nfunc main() {\n\tvar p lib.Nope\n}\n",
+			err:  "module 'lib' has no member 'Nope'",
+		},
+		{
+			name: "redeclared import",
+			src:  "import \"lib\"\n\nvar lib = 1\n",
+			err:  "cannot redeclare identifier 'lib'",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			imported := processSource(t, lib)
+			mod := processModule(t, test.src, map[string]*Module{"lib": &imported})
+
+			checkErrors(t, mod, test.err)
+		})
+	}
+}
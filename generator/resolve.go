@@ -0,0 +1,451 @@
+package generator
+
+import (
+	"fmt"
+	"main/parser"
+	"sort"
+	"strings"
+)
+
+// A method declaration, which is declared along with its type.
+type pendingMethod struct {
+	node   parser.MethodDeclarationNode
+	public bool
+}
+
+// The top-level declarations of a module, which are resolved the first time
+// they're looked up, so they can be declared in any order.
+//
+// Same as go, a declaration can't depend on itself, ie `const a = b; const b =
+// a`.  This includes variables which depend on themselves through functions,
+// ie `var a = f(); func f() int { return a }`.
+type declarations struct {
+	// The declarations which haven't been resolved yet, by name.
+	pending map[string]parser.NamedNode
+	// Every declaration by name, for reporting cycles.
+	nodes map[string]parser.NamedNode
+	// The first name of declarations of several variables, by their other
+	// names, ie `a` for `b` in `var a, b = f()`.  They're resolved together.
+	aliases map[string]string
+	// The names of the declarations in the order they were declared.
+	order []string
+	// The methods which haven't been declared yet, by the name of their type.
+	methods map[string][]pendingMethod
+	// The declarations being resolved, innermost last.  Includes the bodies of
+	// functions, ie `f` or `T.m`.
+	stack []string
+	// Declarations which failed to resolve (which has already been reported).
+	failed map[string]bool
+	// The declarations each variable and function refers to.
+	refers map[string][]string
+	// Function bodies, which are processed after every declaration.
+	bodies []func()
+	// Variables in the order they were resolved, which is the order they have to
+	// be initialised in.  Either Declare or AssignTuple steps.
+	variables []Step
+	// The functions with bodies, for working out which can throw.
+	functions []*Function
+	// Calls in variable initialisers, which can't throw.
+	uncaught []throwSite
+	// Calls started by `start`, which can't throw either.
+	started []throwSite
+	// Calls whose error is caught, which have to be able to throw.
+	catches []throwSite
+}
+
+func newDeclarations() *declarations {
+	return &declarations{
+		pending: map[string]parser.NamedNode{},
+		nodes:   map[string]parser.NamedNode{},
+		aliases: map[string]string{},
+		methods: map[string][]pendingMethod{},
+		failed:  map[string]bool{},
+		refers:  map[string][]string{},
+	}
+}
+
+// Adds a top-level declaration to be resolved later.
+func (s *Scope) addDeclaration(node parser.NamedNode, public bool) {
+	d := s.decls
+
+	if method, ok := node.(parser.MethodDeclarationNode); ok {
+		d.methods[method.MethodOf] = append(d.methods[method.MethodOf], pendingMethod{method, public})
+		return
+	}
+
+	names := []string{node.Name()}
+
+	if tuple, ok := node.(parser.TupleDeclarationNode); ok {
+		names = names[:0]
+
+		for _, name := range tuple.Names {
+			if name.Target != "_" {
+				names = append(names, name.Target)
+			}
+		}
+
+		if len(names) == 0 {
+			names = []string{node.Name()}
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := d.nodes[name]; ok {
+			s.error(node, "cannot redeclare identifier '%s'", name)
+			return
+		}
+
+		if _, ok := s.Identifiers[name]; ok {
+			s.error(node, "cannot redeclare identifier '%s'", name)
+			return
+		}
+	}
+
+	for _, name := range names {
+		d.nodes[name] = node
+
+		if name != names[0] {
+			d.aliases[name] = names[0]
+		}
+	}
+
+	d.pending[names[0]] = node
+	d.order = append(d.order, names[0])
+}
+
+// The chain of declarations from name to the innermost one being resolved, if
+// name is being resolved.
+func (d *declarations) cycleTo(name string) []string {
+	for i, resolving := range d.stack {
+		if resolving == name {
+			return append(append([]string{}, d.stack[i:]...), name)
+		}
+	}
+
+	return nil
+}
+
+// The chain of declarations from typ to the innermost one being resolved, if
+// typ is a type of this module which is still being resolved.
+func (s *Scope) resolvingType(typ Type) []string {
+	d := s.decls
+
+	if d == nil || s.Identifiers[typ.Name()] != typ {
+		return nil
+	}
+
+	for i, resolving := range d.stack {
+		if resolving == typ.Name() {
+			return append([]string{}, d.stack[i:]...)
+		}
+	}
+
+	return nil
+}
+
+// Describes a cycle, ie "a refers to b, b refers to a".
+func describeCycle(chain []string) string {
+	refs := make([]string, len(chain)-1)
+
+	for i := range refs {
+		refs[i] = fmt.Sprintf("%s refers to %s", chain[i], chain[i+1])
+	}
+
+	return strings.Join(refs, ", ")
+}
+
+// Records that the innermost declaration being resolved refers to name.
+func (d *declarations) refer(name string) {
+	if len(d.stack) == 0 {
+		return
+	}
+
+	from := d.stack[len(d.stack)-1]
+	d.refers[from] = append(d.refers[from], name)
+}
+
+// Resolves a top-level declaration which is being referred to by node (or nil
+// if it shouldn't be reported).  Returns false if there's no such declaration.
+func (s *Scope) resolve(name string, node parser.AstNode) (any, bool) {
+	d := s.decls
+
+	if d == nil {
+		return nil, false
+	}
+
+	if val, ok := s.Identifiers[name]; ok {
+		d.refer(name)
+		return val, true
+	}
+
+	// The name the declaration is resolved as.
+	key := name
+	if first, ok := d.aliases[name]; ok {
+		key = first
+	}
+
+	if d.failed[key] {
+		return nil, true
+	}
+
+	if chain := d.cycleTo(key); chain != nil {
+		if node != nil {
+			chain[len(chain)-1] = name
+			s.error(node, "initialization cycle: %s", describeCycle(chain))
+		}
+
+		return nil, true
+	}
+
+	decl, ok := d.pending[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	delete(d.pending, key)
+	d.refer(name)
+
+	d.stack = append(d.stack, key)
+	s.declare(decl)
+	d.stack = d.stack[:len(d.stack)-1]
+
+	val, ok := s.Identifiers[name]
+
+	if !ok {
+		d.failed[key] = true
+		return nil, true
+	}
+
+	// Types are resolved before their methods, so methods can refer to them.
+	if _, ok := val.(Type); ok {
+		s.declareMethods(name)
+	}
+
+	return val, true
+}
+
+func (s *Scope) declare(node parser.NamedNode) {
+	switch node := node.(type) {
+	case parser.VariableDeclarationNode:
+		if dec := s.declareVariable(node); dec.Variable != nil {
+			s.decls.variables = append(s.decls.variables, dec)
+		}
+	case parser.TupleDeclarationNode:
+		if step := s.declareTuple(node); step != nil {
+			s.decls.variables = append(s.decls.variables, step)
+
+			// The other variables depend on the same declarations as the first.
+			var first string
+
+			for _, name := range node.Names {
+				switch {
+				case name.Target == "_":
+				case first == "":
+					first = name.Target
+				default:
+					s.decls.refers[name.Target] = s.decls.refers[first]
+				}
+			}
+		}
+	case parser.ConstantDeclarationNode:
+		s.declareConstant(node)
+	case parser.StructDeclarationNode:
+		s.declareStruct(node)
+	case parser.EnumDeclarationNode:
+		s.declareEnum(node)
+	case parser.InterfaceDeclarationNode:
+		s.declareInterface(node)
+	case parser.ModuleFunctionDeclarationNode:
+		fn := s.handleTopLevelFunction(node.Body)
+		fn.Name = node.Name()
+		s.Identifiers[node.Name()] = fn
+
+		s.addBody(fn.Name, fn, node.Body.Block)
+	}
+}
+
+func (s *Scope) declareMethods(typ string) {
+	methods := s.decls.methods[typ]
+	delete(s.decls.methods, typ)
+
+	for _, m := range methods {
+		if fn := s.declareMethod(m.node); fn != nil {
+			fn.Public = m.public
+			s.addBody(typ+"."+fn.Name, fn, m.node.Body.Block)
+		}
+	}
+}
+
+// Processes fn's body once every declaration has been resolved.
+func (s *Scope) addBody(name string, fn *Function, block parser.BlockNode) {
+	s.decls.functions = append(s.decls.functions, fn)
+	s.decls.bodies = append(s.decls.bodies, func() {
+		s.decls.stack = append(s.decls.stack, name)
+		fn.handleBody(block)
+		s.decls.stack = s.decls.stack[:len(s.decls.stack)-1]
+	})
+}
+
+// Resolves every declaration of the module, then the bodies of its functions.
+func (s *Scope) resolveAll() {
+	d := s.decls
+
+	for _, name := range d.order {
+		s.resolve(name, nil)
+	}
+
+	// Methods of types which don't exist (or can't have methods), which are
+	// reported by declareMethod.
+	types := make([]string, 0, len(d.methods))
+	for typ := range d.methods {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+
+	for _, typ := range types {
+		s.declareMethods(typ)
+	}
+
+	for _, body := range d.bodies {
+		body()
+	}
+
+	s.checkInitCycles()
+	s.checkThrows()
+
+	d.variables = d.initOrder()
+}
+
+// The variables in the order they have to be initialised in: same as go, each
+// one after everything it depends on, including through the functions it
+// calls, and otherwise in the order they were resolved.
+//
+// Methods aren't referred to by name, so depending on a type is taken to
+// depend on everything its methods do.
+func (d *declarations) initOrder() []Step {
+	steps := map[string]int{}
+
+	for i, step := range d.variables {
+		switch step := step.(type) {
+		case Declare:
+			steps[step.Variable.Name] = i
+		case AssignTuple:
+			for _, v := range step.Declares {
+				steps[v.Name] = i
+			}
+		}
+	}
+
+	methods := map[string][]string{}
+
+	for name := range d.refers {
+		if typ, _, ok := strings.Cut(name, "."); ok {
+			methods[typ] = append(methods[typ], name)
+		}
+	}
+
+	for _, names := range methods {
+		sort.Strings(names)
+	}
+
+	var (
+		order   = make([]Step, 0, len(d.variables))
+		added   = make([]bool, len(d.variables))
+		visited = map[string]bool{}
+		visit   func(name string)
+	)
+
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+
+		visited[name] = true
+
+		for _, ref := range d.refers[name] {
+			visit(ref)
+		}
+
+		for _, method := range methods[name] {
+			visit(method)
+		}
+
+		if i, ok := steps[name]; ok && !added[i] {
+			added[i] = true
+			order = append(order, d.variables[i])
+		}
+	}
+
+	for i, step := range d.variables {
+		switch step := step.(type) {
+		case Declare:
+			visit(step.Variable.Name)
+		case AssignTuple:
+			for _, v := range step.Declares {
+				visit(v.Name)
+			}
+		}
+
+		// ie `var _, _ = f()`, which doesn't declare anything to depend on.
+		if !added[i] {
+			added[i] = true
+			order = append(order, step)
+		}
+	}
+
+	return order
+}
+
+// Reports variables which depend on themselves through a function.  Cycles
+// which don't go through a function are reported as they're resolved.
+func (s *Scope) checkInitCycles() {
+	d := s.decls
+	reported := map[string]bool{}
+
+	var variables []*Variable
+
+	for _, step := range d.variables {
+		switch step := step.(type) {
+		case Declare:
+			variables = append(variables, step.Variable)
+		case AssignTuple:
+			variables = append(variables, step.Declares...)
+		}
+	}
+
+	for _, dec := range variables {
+		if reported[dec.Name] {
+			continue
+		}
+
+		visited := map[string]bool{}
+
+		var find func(name string, chain []string) []string
+		find = func(name string, chain []string) []string {
+			for _, ref := range d.refers[name] {
+				if ref == dec.Name {
+					return append(chain, ref)
+				}
+
+				if !visited[ref] {
+					visited[ref] = true
+
+					if found := find(ref, append(chain, ref)); found != nil {
+						return found
+					}
+				}
+			}
+
+			return nil
+		}
+
+		if chain := find(dec.Name, []string{dec.Name}); chain != nil {
+			for _, name := range chain {
+				reported[name] = true
+			}
+
+			s.error(d.nodes[dec.Name], "initialization cycle: %s", describeCycle(chain))
+		}
+	}
+}
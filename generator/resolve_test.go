@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The names of the variables a module initialises, in order.
+func initialised(mod Module) []string {
+	var names []string
+
+	for _, step := range mod.Declarations {
+		switch step := step.(type) {
+		case Declare:
+			names = append(names, step.Variable.Name)
+		case AssignTuple:
+			for _, v := range step.Declares {
+				names = append(names, v.Name)
+			}
+		}
+	}
+
+	return names
+}
+
+func TestInitOrder(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "declaration order",
+			src:  "var a = 1\nvar b = 2\n",
+			want: []string{"a", "b"},
+		},
+		{
+			name: "direct dependency",
+			src:  "var a = b\nvar b = 2\n",
+			want: []string{"b", "a"},
+		},
+		{
+			name: "dependency through a function",
+			src:  "var a = f()\n\nfunc f() int {\n\treturn b\n}\n\nvar b = 2\n",
+			want: []string{"b", "a"},
+		},
+		{
+			name: "dependency through several functions",
+			src:  "var a = f()\n\nfunc f() int {\n\treturn g()\n}\n\nfunc g() int {\n\treturn b + c\n}\n\nvar b = 2\nvar c = b\n",
+			want: []string{"b", "c", "a"},
+		},
+		{
+			name: "dependency through a method",
+			src:  "struct T {\n\tn int\n}\n\nfunc T.get() int {\n\treturn b\n}\n\nvar a = T{}.get()\nvar b = 2\n",
+			want: []string{"b", "a"},
+		},
+		{
+			name: "dependency of a tuple",
+			src:  "var a, b = f()\n\nfunc f() (int, int) {\n\treturn c, c\n}\n\nvar c = 2\n",
+			want: []string{"c", "a", "b"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := initialised(processSource(t, test.src)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("initialised %v; want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,522 @@
+package generator
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"main/parser"
+	"math"
+)
+
+// A slice type, ie `[]int`.
+type Slice struct {
+	Elem Type
+}
+
+func (s *Slice) Kind() Kind {
+	return KindSlice
+}
+
+func (s *Slice) Name() string {
+	return "[]" + s.Elem.Name()
+}
+
+func (s *Slice) Zero() any {
+	return nil
+}
+
+func (s *Slice) AssignableTo(other Type) bool {
+	if other == nil {
+		return true
+	}
+
+	if iface, ok := other.(*Interface); ok {
+		return unimplemented(s, iface) == ""
+	}
+
+	return identical(s, other)
+}
+
+// An array type, ie `[3]int`.  Same as go, arrays are values: assigning one
+// copies its elements.
+type Array struct {
+	Elem Type
+	Len  int
+}
+
+func (a *Array) Kind() Kind {
+	return KindArray
+}
+
+func (a *Array) Name() string {
+	return fmt.Sprintf("[%d]%s", a.Len, a.Elem.Name())
+}
+
+func (a *Array) Zero() any {
+	return nil
+}
+
+func (a *Array) AssignableTo(other Type) bool {
+	if other == nil {
+		return true
+	}
+
+	if iface, ok := other.(*Interface); ok {
+		return unimplemented(a, iface) == ""
+	}
+
+	return identical(a, other)
+}
+
+// A slice of values, ie `[]int{1, 2}` or the values passed to a variadic
+// argument.
+type SliceValue struct {
+	Values []Typed
+	typ    *Slice
+}
+
+func (s SliceValue) Type() Type {
+	return s.typ
+}
+
+// An array of values, ie `[2]int{1, 2}`.
+type ArrayValue struct {
+	Values []Typed
+	typ    *Array
+}
+
+func (a ArrayValue) Type() Type {
+	return a.typ
+}
+
+// An element of an array, slice or string, ie `a[i]`.  Same as go, indexing a
+// string gives the byte at i (not the rune).  Backends check the index is in
+// bounds, unless it's a constant (which has already been checked against an
+// array's length).
+type Index struct {
+	// The array, slice or string being indexed.
+	Of    Typed
+	Index Typed
+	typ   Type
+}
+
+func (i Index) Type() Type {
+	return i.typ
+}
+
+func (Index) isWriteable() {}
+
+// A slice of an array or slice, ie `a[1:3]`, which refers to the same elements.
+// Backends check that `0 <= Low <= High <= cap(Of)`.  Slicing a string gives the
+// string of the bytes between Low and High, and is checked against its length
+// instead.
+type SliceExpression struct {
+	Of Typed
+	// The bounds of the slice; nil if they were omitted, in which case they're 0
+	// and `len(Of)`.
+	Low, High Typed
+	typ       Type
+}
+
+func (s SliceExpression) Type() Type {
+	return s.typ
+}
+
+// The length or capacity of a slice, ie `len(a)` or `cap(a)`, or the length of
+// a string in bytes.  The length of an array or a constant string is a constant.
+type Length struct {
+	Of Typed
+	// Whether this is `cap(a)`.
+	Capacity bool
+}
+
+func (Length) Type() Type {
+	return genericInt
+}
+
+// The type of `[n]T`.  `[...]T` is only allowed in array literals, whose
+// length is the number of elements.
+func (s *Scope) arrayType(node parser.ArrayPrefixNode) Type {
+	if node.Len == nil {
+		s.error(node, "invalid use of [...] array (outside an array literal)")
+		return nil
+	}
+
+	elem := s.getType(node.ArrayOf)
+
+	if elem == nil {
+		return nil
+	}
+
+	n := s.preEvaluate(node.Len)
+
+	if n == nil {
+		return nil
+	}
+
+	c, ok := n.(ConstantValue)
+
+	if !ok || !c.typ.Kind().isInteger() {
+		s.error(node.Len, "invalid array length: expected an integer constant; received value of type '%s'", n.Type().Name())
+		return nil
+	}
+
+	length, exact := constant.Int64Val(c.value)
+
+	switch {
+	case !exact || length > math.MaxInt32:
+		s.error(node.Len, "invalid array length %s: too big", describeValue(c.value))
+		return nil
+	case length < 0:
+		s.error(node.Len, "invalid array length %s: must not be negative", describeValue(c.value))
+		return nil
+	}
+
+	return &Array{Elem: elem, Len: int(length)}
+}
+
+// Evaluates `[n]T{...}` or `[...]T{...}`.
+func (s *Scope) evaluateArray(node parser.ArrayValueNode) Typed {
+	if node.Prefix.Len != nil {
+		typ := s.getType(node.Prefix)
+
+		if typ == nil {
+			return nil
+		}
+
+		return s.evaluateList(typ, node, node.Elements)
+	}
+
+	elem := s.getType(node.Prefix.ArrayOf)
+
+	if elem == nil {
+		return nil
+	}
+
+	values, ok := s.evaluateElements(elem, -1, node.Elements)
+
+	if !ok {
+		return nil
+	}
+
+	return ArrayValue{Values: values, typ: &Array{Elem: elem, Len: len(values)}}
+}
+
+// Evaluates the elements of a literal of typ, which should be an array or a
+// slice.  node is the whole literal, for errors.
+func (s *Scope) evaluateList(typ Type, node parser.AstNode, list parser.ElementListNode) Typed {
+	switch typ := typ.(type) {
+	case *Array:
+		if values, ok := s.evaluateElements(typ.Elem, typ.Len, list); ok {
+			return ArrayValue{Values: values, typ: typ}
+		}
+	case *Slice:
+		if values, ok := s.evaluateElements(typ.Elem, -1, list); ok {
+			return SliceValue{Values: values, typ: typ}
+		}
+	default:
+		s.error(node, "invalid composite literal type '%s'", typ.Name())
+	}
+
+	return nil
+}
+
+// Evaluates the elements of an array or slice literal, ie the `{1, 2}` of
+// `[]int{1, 2}`.  Same as go, elements can have constant indexes (`{2: a}`),
+// and elements without one follow the previous element.  Elements which
+// aren't given are zero.
+//
+// length is the length of the array, or -1 if the literal is as long as its
+// elements need.
+func (s *Scope) evaluateElements(elem Type, length int, list parser.ElementListNode) ([]Typed, bool) {
+	elems, ok := list.(parser.ElementsNode)
+
+	if !ok {
+		// Already reported by the parser.
+		return nil, false
+	}
+
+	var (
+		values = []Typed{}
+		index  = 0
+	)
+
+	for _, e := range elems.Elements {
+		if e.Key != nil {
+			key := s.preEvaluate(e.Key)
+
+			if key == nil {
+				return nil, false
+			}
+
+			c, ok := key.(ConstantValue)
+			n, exact := int64(0), false
+
+			if ok && c.typ.Kind().isInteger() {
+				n, exact = constant.Int64Val(c.value)
+			}
+
+			if !exact || n < 0 || n > math.MaxInt32 {
+				s.error(e.Key, "index must be a non-negative integer constant")
+				return nil, false
+			}
+
+			index = int(n)
+		}
+
+		if length >= 0 && index >= length {
+			s.error(e, "index %d out of bounds [0:%d]", index, length)
+			return nil, false
+		}
+
+		if index < len(values) && values[index] != nil {
+			s.error(e, "duplicate index %d in array or slice literal", index)
+			return nil, false
+		}
+
+		val := s.evaluateElement(elem, e.Value)
+
+		if val == nil {
+			return nil, false
+		}
+
+		for len(values) <= index {
+			values = append(values, nil)
+		}
+
+		values[index] = val
+		index++
+	}
+
+	for len(values) < length {
+		values = append(values, nil)
+	}
+
+	for i, val := range values {
+		if val == nil {
+			values[i] = zeroValue(elem)
+		}
+	}
+
+	return values, true
+}
+
+// Evaluates an element of an array or slice literal.  Same as go, the type of
+// a composite element can be left out, ie `[][]int{{1}, {2}}`.
+func (s *Scope) evaluateElement(elem Type, node parser.ValueNode) Typed {
+	if list, ok := node.(parser.ElementListNode); ok {
+		return s.compositeOf(elem, list, list)
+	}
+
+	val := s.preEvaluate(node)
+
+	if val == nil {
+		return nil
+	}
+
+	if !val.Type().AssignableTo(elem) {
+		s.error(node, "unable to use value of type '%s' as element of type '%s'%s", val.Type().Name(), elem.Name(), unassignable(val.Type(), elem))
+		return nil
+	}
+
+	return assignedTo(val, elem)
+}
+
+// Evaluates `a[i]`.
+func (s *Scope) evaluateIndex(node parser.IndexNode) Typed {
+	of := s.preEvaluate(node.IndexOf)
+
+	if of == nil {
+		return nil
+	}
+
+	var (
+		elem   Type
+		length = -1
+	)
+
+	switch typ := of.Type().(type) {
+	case *Array:
+		elem, length = typ.Elem, typ.Len
+	case *Slice:
+		elem = typ.Elem
+	default:
+		if typ.Kind() != KindString {
+			s.error(node, "invalid operation: cannot index value of type '%s'", of.Type().Name())
+			return nil
+		}
+
+		elem, length = genericUint8, constantLength(of)
+	}
+
+	index := s.evaluateBound(node.Key, length)
+
+	if index == nil {
+		return nil
+	}
+
+	return Index{Of: of, Index: index, typ: elem}
+}
+
+// Evaluates `a[low:high]`.
+func (s *Scope) evaluateSlice(node parser.SliceNode) Typed {
+	of := s.preEvaluate(node.SliceOf)
+
+	if of == nil {
+		return nil
+	}
+
+	var (
+		expr  = SliceExpression{Of: of}
+		limit = -1
+	)
+
+	switch typ := of.Type().(type) {
+	case *Array:
+		// The slice refers to the array's elements, so they have to be stored
+		// somewhere, same as go.
+		if !isAssignable(of) {
+			s.error(node.SliceOf, "invalid operation: cannot slice unaddressable value of type '%s'", typ.Name())
+			return nil
+		}
+
+		expr.typ, limit = &Slice{Elem: typ.Elem}, typ.Len+1
+	case *Slice:
+		expr.typ = typ
+	default:
+		if typ.Kind() != KindString {
+			s.error(node, "invalid operation: cannot slice value of type '%s'", of.Type().Name())
+			return nil
+		}
+
+		expr.typ = typ
+
+		if n := constantLength(of); n >= 0 {
+			limit = n + 1
+		}
+	}
+
+	if node.Low != nil {
+		if expr.Low = s.evaluateBound(node.Low, limit); expr.Low == nil {
+			return nil
+		}
+	}
+
+	if node.High != nil {
+		if expr.High = s.evaluateBound(node.High, limit); expr.High == nil {
+			return nil
+		}
+	}
+
+	low, lowConst := expr.Low.(ConstantValue)
+	high, highConst := expr.High.(ConstantValue)
+
+	if lowConst && highConst && constant.Compare(low.value, token.GTR, high.value) {
+		s.error(node, "invalid slice indices: %s < %s", high.value, low.value)
+		return nil
+	}
+
+	return expr
+}
+
+// Evaluates an index, or a bound of a slice expression, which has to be an
+// integer.  If it's a constant, it also has to be non-negative and less than
+// limit (unless limit is -1).
+func (s *Scope) evaluateBound(node parser.ValueNode, limit int) Typed {
+	val := s.preEvaluate(node)
+
+	if val == nil {
+		return nil
+	}
+
+	c, isConst := val.(ConstantValue)
+
+	// Untyped floats can be used as integers if they are one, ie `a[1.0]`.
+	if isConst && c.typ.Kind() == kindUntypedFloat {
+		if v := constant.ToInt(c.value); v.Kind() == constant.Int {
+			c = untyped(v)
+			val = c
+		}
+	}
+
+	if !val.Type().Kind().isInteger() {
+		s.error(node, "invalid argument: index of type '%s' must be an integer", val.Type().Name())
+		return nil
+	}
+
+	if !isConst {
+		return val
+	}
+
+	switch {
+	case constant.Sign(c.value) < 0:
+		s.error(node, "invalid argument: index %s must not be negative", describeValue(c.value))
+		return nil
+	case limit >= 0 && constant.Compare(c.value, token.GEQ, constant.MakeInt64(int64(limit))):
+		s.error(node, "invalid argument: index %s out of bounds [0:%d]", describeValue(c.value), limit)
+		return nil
+	}
+
+	if isUntyped(c.typ) {
+		return s.constantOfType(node, c.value, genericInt)
+	}
+
+	return c
+}
+
+// The builtin function node names, if it names one, ie the `len` in `len(a)`.
+func (s *Scope) builtinNamed(node parser.ValueNode) builtin {
+	ident, ok := node.(parser.IdentifierNode)
+
+	if !ok || s.Lookup(ident.Target) != nil {
+		return ""
+	}
+
+	fn, _ := predeclared[ident.Target].(builtin)
+	return fn
+}
+
+// Evaluates a call to a builtin function.
+func (s *Scope) evaluateBuiltin(fn builtin, node parser.CallNode) Typed {
+	if len(node.Arguments) != 1 || node.Spread {
+		s.error(node, "expected exactly one argument to %s()", fn)
+		return nil
+	}
+
+	val := s.preEvaluate(node.Arguments[0])
+
+	if val == nil {
+		return nil
+	}
+
+	switch typ := val.Type().(type) {
+	case *Array:
+		// Same as go, the length of an array is a constant, unless it's the
+		// result of a call (which still has to be made).
+		if _, ok := val.(Call); !ok {
+			return constantOf(int64(typ.Len), genericInt)
+		}
+	case *Slice:
+	default:
+		// Strings have a length, but not a capacity.
+		if typ.Kind() != KindString || fn == "cap" {
+			s.error(node.Arguments[0], "invalid argument: value of type '%s' for %s()", val.Type().Name(), fn)
+			return nil
+		}
+
+		if n := constantLength(val); n >= 0 {
+			return constantOf(int64(n), genericInt)
+		}
+	}
+
+	return Length{Of: val, Capacity: fn == "cap"}
+}
+
+// The length in bytes of val if it's a constant string, or -1.
+func constantLength(val Typed) int {
+	if c, ok := val.(ConstantValue); ok && c.value != nil && c.value.Kind() == constant.String {
+		return len(constant.StringVal(c.value))
+	}
+
+	return -1
+}
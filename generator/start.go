@@ -0,0 +1,32 @@
+package generator
+
+import "main/parser"
+
+// `start f(x)`, which calls f without waiting for it to return, so it runs
+// alongside the caller.  Same as go, the arguments are evaluated straight away
+// and any results are discarded.
+type Start struct {
+	Call Call
+}
+
+func (Start) isStep() {}
+
+func (s *Scope) handleStart(node parser.StartNode) Step {
+	if s.typeNamed(node.Call.Callee) != nil {
+		s.error(node, "expression in start must be function call")
+		return nil
+	}
+
+	// The call isn't a throw site of the caller; there's nothing to throw its
+	// errors to.
+	call := s.evaluateCall(node.Call)
+
+	if call.Target == nil {
+		return nil
+	}
+
+	d := moduleScope(s).(*Scope).decls
+	d.started = append(d.started, throwSite{node: node.Call, call: call.Target})
+
+	return Start{Call: call}
+}
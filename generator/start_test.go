@@ -0,0 +1,30 @@
+package generator
+
+import "testing"
+
+func TestStart(t *testing.T) {
+	runSourceTests(t, []sourceTest{
+		{
+			name: "function",
+			src:  "func f(a int) {\n}\n\nfunc main() {\n\tstart f(1)\n}\n",
+		},
+		{
+			name: "results discarded",
+			src:  "func f() int {\n\treturn 1\n}\n\nfunc main() {\n\tstart f()\n}\n",
+		},
+		{
+			name: "method",
+			src:  "struct T {\n\tn int\n}\n\nfunc T.m() {\n}\n\nfunc main() {\n\tstart T{}.m()\n}\n",
+		},
+		{
+			name: "arguments",
+			src:  "func f(a int) {\n}\n\nfunc main() {\n\tstart f(\"x\")\n}\n",
+			err:  "invalid argument type 'string'; expected 'int'",
+		},
+		{
+			name: "throws",
+			src:  "struct E {\n\tmsg string\n}\n\nfunc E.Error() string {\n\treturn this.msg\n}\n\nfunc f() {\n\tthrow E{\"x\"}\n}\n\nfunc main() {\n\tstart f()\n}\n",
+			err:  "unhandled error: f() can throw, but started functions can't",
+		},
+	})
+}
@@ -0,0 +1,452 @@
+package generator
+
+import "main/parser"
+
+// A field of a struct.
+type Field struct {
+	Name string
+	Type Type
+	// Whether the field is embedded, in which case the fields of its type are
+	// promoted to the struct.
+	Embedded bool
+}
+
+type Struct struct {
+	name   string
+	Fields []Field
+	// The methods declared on the struct, in the order they were declared.
+	Methods []*Function
+}
+
+func (s *Struct) Kind() Kind {
+	return KindStruct
+}
+
+func (s *Struct) Name() string {
+	return s.name
+}
+
+// The zero value of each of the struct's fields, in order.
+func (s *Struct) Zero() any {
+	zero := make([]any, len(s.Fields))
+
+	for i, field := range s.Fields {
+		zero[i] = field.Type.Zero()
+	}
+
+	return zero
+}
+
+// Structs are only assignable to the same struct (two structs with the same
+// fields are still different types), or interfaces they implement.
+func (s *Struct) AssignableTo(other Type) bool {
+	if other == nil {
+		return true
+	}
+
+	if iface, ok := other.(*Interface); ok {
+		return unimplemented(s, iface) == ""
+	}
+
+	o, _ := other.(*Struct)
+	return s == o
+}
+
+// Finds the field or method with the given name, including ones promoted from
+// embedded structs.  Returns the index of the field in each struct along the
+// way (for a method, up to the struct it was declared on), or nil if there's no
+// such field or method (or it's ambiguous, in which case ambiguous is set).
+//
+// Same as go, promoted fields and methods at a shallower depth take priority;
+// two at the same depth are ambiguous.
+func (s *Struct) lookupSelector(name string) (path []int, method *Function, ambiguous bool) {
+	type candidate struct {
+		st   *Struct
+		path []int
+	}
+
+	for depth := []candidate{{st: s}}; len(depth) > 0; {
+		var (
+			next  []candidate
+			found bool
+		)
+
+		for _, c := range depth {
+			if m := c.st.method(name); m != nil {
+				if found {
+					return nil, nil, true
+				}
+
+				found, path, method = true, c.path, m
+			}
+
+			for i, field := range c.st.Fields {
+				fieldPath := append(append([]int{}, c.path...), i)
+
+				if field.Name == name {
+					if found {
+						return nil, nil, true
+					}
+
+					found, path = true, fieldPath
+				}
+
+				if embedded, ok := field.Type.(*Struct); ok && field.Embedded {
+					next = append(next, candidate{st: embedded, path: fieldPath})
+				}
+			}
+		}
+
+		if found {
+			if path == nil {
+				path = []int{}
+			}
+
+			return path, method, false
+		}
+
+		depth = next
+	}
+
+	return nil, nil, false
+}
+
+// A method promoted to a struct from one of its embedded structs.
+type PromotedMethod struct {
+	*Function
+	// The index of the embedded field in each struct on the way to the struct
+	// the method was declared on.
+	Path []int
+}
+
+// The methods promoted to s from its embedded structs, leaving out any which are
+// shadowed or ambiguous.
+func (s *Struct) Promoted() (promoted []PromotedMethod) {
+	seen := map[string]bool{}
+
+	var visit func(st *Struct)
+	visit = func(st *Struct) {
+		for _, field := range st.Fields {
+			embedded, ok := field.Type.(*Struct)
+
+			if !ok || !field.Embedded {
+				continue
+			}
+
+			for _, m := range embedded.Methods {
+				if seen[m.Name] {
+					continue
+				}
+				seen[m.Name] = true
+
+				if path, method, _ := s.lookupSelector(m.Name); method != nil && len(path) > 0 {
+					promoted = append(promoted, PromotedMethod{Function: method, Path: path})
+				}
+			}
+
+			visit(embedded)
+		}
+	}
+
+	visit(s)
+
+	return
+}
+
+func (s *Struct) method(name string) *Function {
+	for _, m := range s.Methods {
+		if m.Name == name {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// A value of a struct type, ie `T{a: 1}`.
+type StructValue struct {
+	typ *Struct
+	// The value of each of the struct's fields, in order.
+	Fields []Typed
+}
+
+func (s StructValue) Type() Type {
+	return s.typ
+}
+
+// Access to a field of a struct, ie `a.b`.
+type FieldAccess struct {
+	// The struct being accessed.
+	Of Typed
+	// The index of the field in the struct.
+	Index int
+	Field Field
+}
+
+func (f FieldAccess) Type() Type {
+	return f.Field.Type
+}
+
+func (FieldAccess) isWriteable() {}
+
+// The value of typ when nothing was assigned to it.
+func zeroValue(typ Type) Typed {
+	switch typ := typ.(type) {
+	case *Struct:
+		val := StructValue{typ: typ, Fields: make([]Typed, len(typ.Fields))}
+
+		for i, field := range typ.Fields {
+			val.Fields[i] = zeroValue(field.Type)
+		}
+
+		return val
+	case *Array:
+		val := ArrayValue{typ: typ, Values: make([]Typed, typ.Len)}
+
+		for i := range val.Values {
+			val.Values[i] = zeroValue(typ.Elem)
+		}
+
+		return val
+	}
+
+	return constantOf(typ.Zero(), typ)
+}
+
+// Whether a value can be assigned to: a variable, an argument, or a field or
+// element of either.
+func isAssignable(val Typed) bool {
+	for {
+		switch v := val.(type) {
+		case FieldAccess:
+			val = v.Of
+		case Index:
+			// Slices refer to their elements, so they can always be assigned to,
+			// and strings are immutable.
+			switch v.Of.Type().Kind() {
+			case KindSlice:
+				return true
+			case KindString:
+				return false
+			}
+
+			val = v.Of
+		case Writeable:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+func (s *Scope) declareStruct(node parser.StructDeclarationNode) {
+	name := node.Name()
+	if _, ok := s.Identifiers[name]; ok {
+		s.error(node, "cannot redeclare identifier '%s'", name)
+		return
+	}
+
+	st := &Struct{name: name, Fields: make([]Field, 0, len(node.Fields))}
+
+	// Declared before the fields are resolved so recursive types can be found.
+	s.Identifiers[name] = st
+
+	seen := make(map[string]bool, len(node.Fields))
+
+	for _, field := range node.Fields {
+		typ := s.getType(field.Type)
+
+		if typ == nil {
+			continue
+		}
+
+		if seen[field.Name()] {
+			s.error(field, "duplicate field '%s' in struct '%s'", field.Name(), name)
+			continue
+		}
+		seen[field.Name()] = true
+
+		// A struct which is still being resolved contains this one, ie
+		// `struct T { a T }`.
+		if chain := s.resolvingType(typ); chain != nil {
+			s.error(field, "invalid recursive type '%s': %s", chain[0], describeCycle(append(chain, chain[0])))
+			continue
+		}
+
+		if _, ok := typ.(*Struct); field.IsEmbedded() && !ok {
+			s.error(field, "embedded field '%s' is not a struct", field.Name())
+			continue
+		}
+
+		st.Fields = append(st.Fields, Field{
+			Name:     field.Name(),
+			Type:     typ,
+			Embedded: field.IsEmbedded(),
+		})
+	}
+}
+
+func (s *Scope) evaluatePropertyAccess(node parser.PropertyAccessNode) Typed {
+	if imp := s.importOf(node); imp != nil {
+		return s.evaluateQualified(imp, node)
+	}
+
+	if member, ok := s.evaluateEnumMember(node); ok {
+		return member
+	}
+
+	of := s.preEvaluate(node.PropertyOf)
+
+	if of == nil {
+		return nil
+	}
+
+	st, ok := of.Type().(*Struct)
+
+	if !ok {
+		s.error(node, "type '%s' has no field '%s'", of.Type().Name(), node.Property.Target)
+		return nil
+	}
+
+	path, method, ambiguous := st.lookupSelector(node.Property.Target)
+
+	if ambiguous {
+		s.error(node.Property, "ambiguous selector '%s'", node.Property.Target)
+		return nil
+	}
+
+	if method != nil {
+		s.error(node.Property, "method '%s' must be called", node.Property.Target)
+		return nil
+	}
+
+	if path == nil {
+		s.error(node.Property, "type '%s' has no field '%s'", st.Name(), node.Property.Target)
+		return nil
+	}
+
+	return accessPath(of, path)
+}
+
+// Accesses the field at each index of path in turn, starting from of.
+func accessPath(of Typed, path []int) Typed {
+	for _, index := range path {
+		field := of.Type().(*Struct).Fields[index]
+		of = FieldAccess{Of: of, Index: index, Field: field}
+	}
+
+	return of
+}
+
+func (s *Scope) evaluateComposite(node parser.CompositeValueNode) Typed {
+	typ := s.getType(node.Type)
+
+	if typ == nil {
+		return nil
+	}
+
+	switch typ.(type) {
+	case *Struct, *Array, *Slice:
+		return s.compositeOf(typ, node, node.Elements)
+	}
+
+	s.error(node.Type, "invalid composite literal type '%s'", typ.Name())
+	return nil
+}
+
+// Evaluates the elements of a composite literal of typ, which is a struct, an
+// array or a slice.  node is the whole literal, for errors.
+func (s *Scope) compositeOf(typ Type, node parser.AstNode, list parser.ElementListNode) Typed {
+	st, ok := typ.(*Struct)
+
+	if !ok {
+		return s.evaluateList(typ, node, list)
+	}
+
+	elems, ok := list.(parser.ElementsNode)
+
+	if !ok {
+		// Already reported by the parser.
+		return nil
+	}
+
+	val := StructValue{typ: st, Fields: make([]Typed, len(st.Fields))}
+
+	if len(elems.Elements) > 0 && elems.Elements[0].Key == nil {
+		if len(elems.Elements) != len(st.Fields) {
+			s.error(node, "expected %d values for '%s'; received %d", len(st.Fields), st.Name(), len(elems.Elements))
+			return nil
+		}
+
+		for i, elem := range elems.Elements {
+			if elem.Key != nil {
+				s.error(elem, "mixture of field:value and value elements in struct literal")
+				return nil
+			}
+
+			if val.Fields[i] = s.evaluateField(st.Fields[i], elem.Value); val.Fields[i] == nil {
+				return nil
+			}
+		}
+
+		return val
+	}
+
+	for _, elem := range elems.Elements {
+		if elem.Key == nil {
+			s.error(elem, "mixture of field:value and value elements in struct literal")
+			return nil
+		}
+
+		key, ok := elem.Key.(parser.IdentifierNode)
+
+		if !ok {
+			s.error(elem.Key, "invalid field name in struct literal")
+			return nil
+		}
+
+		index := -1
+		for i, field := range st.Fields {
+			if field.Name == key.Target {
+				index = i
+			}
+		}
+
+		if index < 0 {
+			s.error(key, "unknown field '%s' in struct literal of type '%s'", key.Target, st.Name())
+			return nil
+		}
+
+		if val.Fields[index] != nil {
+			s.error(key, "duplicate field '%s' in struct literal", key.Target)
+			return nil
+		}
+
+		if val.Fields[index] = s.evaluateField(st.Fields[index], elem.Value); val.Fields[index] == nil {
+			return nil
+		}
+	}
+
+	for i, field := range st.Fields {
+		if val.Fields[i] == nil {
+			val.Fields[i] = zeroValue(field.Type)
+		}
+	}
+
+	return val
+}
+
+func (s *Scope) evaluateField(field Field, node parser.ValueNode) Typed {
+	val := s.preEvaluate(node)
+
+	if val == nil {
+		return nil
+	}
+
+	if !val.Type().AssignableTo(field.Type) {
+		s.error(node, "unable to use value of type '%s' as field '%s' of type '%s'%s", val.Type().Name(), field.Name, field.Type.Name(), unassignable(val.Type(), field.Type))
+		return nil
+	}
+
+	return assignedTo(val, field.Type)
+}
@@ -0,0 +1,301 @@
+package generator
+
+import (
+	"go/constant"
+	"main/lexer"
+	"main/parser"
+	"strings"
+)
+
+// A `switch` statement.  Variables declared by Init live in the switch's own
+// scope, same as loops.
+type Switch struct {
+	p     Scoped
+	Scope *Scope
+	// Executed before the tag is evaluated (if any).
+	Init Step
+	// The value each case is compared against; nil for a tagless switch, whose
+	// cases are conditions.
+	Tag Typed
+	// The cases in the order they were declared, including `default`.
+	Cases []Case
+}
+
+// A case of a switch, which matches if the tag is equal to any of its values
+// (or, for a tagless switch, if any of them is true).
+type Case struct {
+	// The values the case matches; nil for `default`, which matches if no other
+	// case does.
+	Values []Typed
+	Block  Block
+	// Whether the case ends with `fallthrough`, which continues into the next
+	// case's block without checking it.
+	Fallthrough bool
+}
+
+func (sw *Switch) parent() Scoped {
+	return sw.p
+}
+
+func (sw *Switch) lookupIdentifier(node parser.IdentifierNode) any {
+	return sw.p.lookupIdentifier(node)
+}
+
+func (sw *Switch) Lookup(name string) any {
+	return sw.p.Lookup(name)
+}
+
+func (sw *Switch) error(node parser.AstNode, str string, rest ...interface{}) {
+	sw.p.error(node, str, rest...)
+}
+
+func (*Switch) isStep() {}
+
+func (s *Scope) handleSwitch(node parser.SwitchNode) Step {
+	sw := &Switch{p: s}
+	sw.Scope = newScope(sw)
+
+	if node.Init != nil {
+		sw.Init = sw.Scope.handleStep(node.Init)
+	}
+
+	if node.Tag != nil {
+		if sw.Tag = sw.Scope.switchTag(node.Tag); sw.Tag == nil {
+			return nil
+		}
+	}
+
+	// The constant cases so far, to report duplicates.
+	seen := map[string]bool{}
+	hasDefault := false
+
+	for i, c := range node.Cases {
+		var cs Case
+
+		if c.Values == nil {
+			hasDefault = true
+		} else {
+			// Invalid values have already been reported, but the case still
+			// isn't a default.
+			cs.Values = make([]Typed, 0, len(c.Values))
+		}
+
+		for _, value := range c.Values {
+			val := sw.Scope.caseValue(sw.Tag, value)
+
+			if val == nil {
+				continue
+			}
+
+			if c, ok := val.(ConstantValue); ok && sw.Tag != nil {
+				key := c.value.ExactString()
+
+				if seen[key] {
+					s.error(value, "duplicate case %s in switch", describeConstant(c, sw.Tag.Type()))
+				}
+
+				seen[key] = true
+			}
+
+			cs.Values = append(cs.Values, val)
+		}
+
+		body := c.Body
+
+		if n := len(body.Steps); n > 0 {
+			if br, ok := body.Steps[n-1].(parser.BranchNode); ok && br.Token == lexer.FALLTHROUGH {
+				if i == len(node.Cases)-1 {
+					s.error(br, "cannot fallthrough final case in switch")
+				} else {
+					cs.Fallthrough = true
+				}
+
+				body.Steps = body.Steps[:n-1]
+			}
+		}
+
+		cs.Block = handleChildBlock(sw.Scope, body)
+		sw.Cases = append(sw.Cases, cs)
+	}
+
+	if enum, ok := typeOf(sw.Tag).(*Enum); ok && !hasDefault {
+		var missing []string
+
+		for i, member := range enum.Members {
+			if !seen[constant.MakeUint64(uint64(i)).ExactString()] {
+				missing = append(missing, member)
+			}
+		}
+
+		if len(missing) > 0 {
+			s.error(node, "missing cases in switch of type '%s': %s", enum.Name(), strings.Join(missing, ", "))
+		}
+	}
+
+	return sw
+}
+
+// The type of val, or nil if val is nil.
+func typeOf(val Typed) Type {
+	if val == nil {
+		return nil
+	}
+
+	return val.Type()
+}
+
+// Evaluates the value a switch compares its cases against.
+func (s *Scope) switchTag(node parser.ValueNode) Typed {
+	tag := s.preEvaluate(node)
+
+	if tag == nil {
+		return nil
+	}
+
+	switch tag.Type().Kind() {
+	case kindUntypedNil:
+		s.error(node, "use of untyped nil in switch expression")
+		return nil
+	case KindInterface, KindSlice:
+		s.error(node, "cannot switch on value of type '%s'; it can only be compared to nil", tag.Type().Name())
+		return nil
+	case kindUntypedInt, kindUntypedFloat:
+		// Same as declaring a variable, `switch 1` is an int.
+		typ := s.defaultType(node, tag.Type())
+
+		if typ == nil {
+			return nil
+		}
+
+		return constantOf(tag.(ConstantValue).value, typ)
+	}
+
+	return tag
+}
+
+// Evaluates one of the values of a case, which has to be comparable to tag (or
+// be a condition, if tag is nil).
+func (s *Scope) caseValue(tag Typed, node parser.ValueNode) Typed {
+	val := s.preEvaluate(node)
+
+	if val == nil {
+		return nil
+	}
+
+	if tag == nil {
+		if !isCondition(val.Type()) {
+			s.error(node, "invalid case: expected a bool; received value of type '%s'", val.Type().Name())
+			return nil
+		}
+
+		return val
+	}
+
+	if !val.Type().AssignableTo(tag.Type()) {
+		s.error(node, "invalid case: expected value of type '%s'; received value of type '%s'%s", tag.Type().Name(), val.Type().Name(), unassignable(val.Type(), tag.Type()))
+		return nil
+	}
+
+	return val
+}
+
+// Describes a constant of typ for an error, ie `Color.Red` or `"a"`.
+func describeConstant(val ConstantValue, typ Type) string {
+	if typ.Kind() == KindEnum {
+		if member, ok := constant.Uint64Val(val.value); ok {
+			return typ.Name() + "." + typ.(*Enum).MemberName(member)
+		}
+	}
+
+	return val.value.String()
+}
+
+// The switch as a chain of `if` statements, for backends which don't have
+// anything better.  The tag is evaluated once, into a variable of its own, and
+// fallthroughs repeat the next case's block.
+//
+// Same as go, `break` ends the switch; a `break` at the end of a case doesn't
+// do anything, so it's dropped, but backends lowering switches this way can't
+// support one anywhere else.
+func (sw *Switch) Lower() Block {
+	block := Block{Scope: sw.Scope}
+
+	if sw.Init != nil {
+		block.Steps = append(block.Steps, sw.Init)
+	}
+
+	tag := sw.Tag
+
+	switch sw.Tag.(type) {
+	case nil, ConstantValue, *Variable:
+	default:
+		v := &Variable{Name: "tag", InitialValue: sw.Tag, typ: sw.Tag.Type()}
+		block.Steps = append(block.Steps, Declare{Name: v.Name, Variable: v})
+		tag = v
+	}
+
+	var (
+		stp    *If
+		def    *Block
+		blocks = make([]Block, len(sw.Cases))
+	)
+
+	// Later cases first, since fallthroughs include the next case's block.
+	for i := len(sw.Cases) - 1; i >= 0; i-- {
+		c := sw.Cases[i]
+		steps := c.Block.Steps
+
+		if n := len(steps); n > 0 {
+			if _, ok := steps[n-1].(Break); ok {
+				steps = steps[:n-1]
+			}
+		}
+
+		if c.Fallthrough && i+1 < len(blocks) {
+			steps = append(append([]Step{}, steps...), blocks[i+1])
+		}
+
+		blocks[i] = Block{Scope: c.Block.Scope, Steps: steps}
+	}
+
+	for i, c := range sw.Cases {
+		if c.Values == nil {
+			def = &blocks[i]
+			continue
+		}
+
+		var cond Typed
+
+		for _, val := range c.Values {
+			if tag != nil {
+				val = BinaryOperation{Left: tag, Right: val, Operator: lexer.EQL}
+			}
+
+			if cond == nil {
+				cond = val
+			} else {
+				cond = BinaryOperation{Left: cond, Right: val, Operator: lexer.BOOLEAN_OR}
+			}
+		}
+
+		if cond == nil {
+			continue
+		}
+
+		if stp == nil {
+			stp = &If{Condition: cond, Then: blocks[i]}
+		} else {
+			stp.ElseIf = append(stp.ElseIf, If{Condition: cond, Then: blocks[i]})
+		}
+	}
+
+	switch {
+	case stp != nil:
+		stp.Else = def
+		block.Steps = append(block.Steps, *stp)
+	case def != nil:
+		block.Steps = append(block.Steps, *def)
+	}
+
+	return block
+}
@@ -0,0 +1,310 @@
+package generator
+
+import (
+	"main/parser"
+	"sort"
+)
+
+// The predeclared error interface, which thrown values have to implement.
+var errorType = func() *Interface {
+	iface := &Interface{name: "error"}
+
+	iface.Methods = []*Function{{
+		Name:     "Error",
+		Args:     []*Argument{},
+		Returns:  genericString,
+		Receiver: &Receiver{typ: iface},
+	}}
+
+	return iface
+}()
+
+// `throw err`, which ends the function and every caller up to the first call
+// which catches the error.
+type Throw struct {
+	// The error, which is stored in an error interface.
+	Value Typed
+}
+
+func (Throw) isStep() {}
+
+// A call which catches the error it throws, ie `a, catch err = f()`.  If the
+// call throws, its results aren't assigned.
+type Catch struct {
+	// The variables declared by the assignment, including Error's.
+	Declares []*Variable
+	// A target for each of the call's results; nil for `_`.
+	Targets []Writeable
+	// Set to the error thrown by the call, or nil if it doesn't throw; nil for
+	// `catch _`.
+	Error Writeable
+	Call  Call
+	// The call's results converted to the types of Targets, same as
+	// AssignTuple's; nil if they're assigned as they are.
+	Converted []Typed
+}
+
+func (Catch) isStep() {}
+
+// Where a function can throw: a `throw` statement, or a call which doesn't
+// catch the error of the function it calls.
+type throwSite struct {
+	node parser.AstNode
+	// The function called; nil for `throw`.
+	call *Function
+}
+
+func (t throwSite) throws() bool {
+	return t.call == nil || t.call.Throws
+}
+
+// Records that the function s is in (or the module's variable initialisers)
+// can throw at site.
+func (s *Scope) addThrowSite(site throwSite) {
+	if fn := s.function(); fn != nil {
+		fn.throws = append(fn.throws, site)
+		return
+	}
+
+	d := moduleScope(s).(*Scope).decls
+	d.uncaught = append(d.uncaught, site)
+}
+
+func (s *Scope) handleThrow(node parser.ThrowNode) Step {
+	// Invalid throws still end the function, so they're kept rather than
+	// reporting a missing return as well.
+	val := s.preEvaluate(node.Value)
+
+	switch {
+	case val == nil:
+		return Throw{}
+	case isNil(val):
+		s.error(node.Value, "cannot throw nil")
+		return Throw{}
+	case !val.Type().AssignableTo(errorType):
+		s.error(node.Value, "cannot throw value of type '%s'%s", val.Type().Name(), unassignable(val.Type(), errorType))
+		return Throw{}
+	}
+
+	s.addThrowSite(throwSite{node: node})
+
+	return Throw{Value: assignedTo(val, errorType)}
+}
+
+// Evaluates the call whose error is caught by `catch`, which has to return n
+// values.
+func (s *Scope) evaluateCaught(node parser.AstNode, values []parser.ValueNode, n int) (Call, bool) {
+	call, ok := values[0].(parser.CallNode)
+
+	if !ok || len(values) != 1 || s.typeNamed(call.Callee) != nil {
+		s.error(node, "catch requires a single function call")
+		return Call{}, false
+	}
+
+	val := s.evaluateCall(call)
+
+	if val.Target == nil {
+		return Call{}, false
+	}
+
+	if results := len(resultTypes(val.Type())); results != n {
+		s.error(node, "assignment mismatch: %s but %s() returns %s", plural(n, "variable"), val.Target.Name, plural(results, "value"))
+		return Call{}, false
+	}
+
+	d := moduleScope(s).(*Scope).decls
+	d.catches = append(d.catches, throwSite{node: call, call: val.Target})
+
+	return val, true
+}
+
+// Converts the assignment of a caught call's results and error to a Catch.
+func caught(step AssignTuple) Catch {
+	last := len(step.Targets) - 1
+
+	c := Catch{
+		Declares: step.Declares,
+		Targets:  step.Targets[:last],
+		Error:    step.Targets[last],
+		Call:     step.Value.(Call),
+	}
+
+	// The error is already an error, so never needs converting.
+	if step.Converted != nil {
+		c.Converted = step.Converted[:last]
+	}
+
+	return c
+}
+
+// Works out which functions can throw, then reports errors which are thrown
+// where they can't be: by variable initialisers and started functions, which
+// don't have a caller, and by main.  Also reports catching errors of calls which can't throw.
+//
+// The functions of imported modules are worked out again, since their calls of
+// interface methods can throw if a type of this module implements the method
+// with one which can, ie `lib.Run(v)` calling `v.do()`.
+func (s *Scope) checkThrows() {
+	d := s.decls
+	scopes := s.loadedScopes()
+	implementations := implementationsIn(scopes)
+
+	// A function can throw if a function it calls can, which might not have
+	// been worked out yet, so this repeats until nothing changes.
+	for changed := true; changed; {
+		changed = false
+
+		for _, scope := range scopes {
+			for _, fn := range scope.decls.functions {
+				if fn.Throws {
+					continue
+				}
+
+				for _, site := range fn.throws {
+					if site.throws() {
+						fn.Throws, changed = true, true
+						break
+					}
+				}
+			}
+		}
+
+		for _, m := range implementations.methods {
+			if m.Throws {
+				continue
+			}
+
+			for _, impl := range implementations.of[m] {
+				if impl.Throws {
+					m.Throws, changed = true, true
+					break
+				}
+			}
+		}
+	}
+
+	for _, site := range d.uncaught {
+		if site.throws() {
+			s.error(site.node, "unhandled error: %s() can throw, but variable initialisers can't", site.call.Name)
+		}
+	}
+
+	for _, site := range d.started {
+		if site.throws() {
+			s.error(site.node, "unhandled error: %s() can throw, but started functions can't", site.call.Name)
+		}
+	}
+
+	if main, ok := s.Identifiers["main"].(*Function); ok && main.Throws {
+		for _, site := range main.throws {
+			switch {
+			case !site.throws():
+			case site.call == nil:
+				s.error(site.node, "unhandled error: main can't throw")
+			default:
+				s.error(site.node, "unhandled error: %s() can throw, but main can't", site.call.Name)
+			}
+		}
+	}
+
+	for _, site := range d.catches {
+		if !site.throws() {
+			s.error(site.node, "%s() can't throw, so there's nothing to catch", site.call.Name)
+		}
+	}
+}
+
+// The methods which implement the methods of interfaces, by the interface's
+// method.
+type methodImplementations struct {
+	// The methods of interfaces, in the order they were found.
+	methods []*Function
+	of      map[*Function][]*Function
+}
+
+// This module's scope followed by the scopes of the modules it imports,
+// including indirectly.
+func (s *Scope) loadedScopes() []*Scope {
+	var (
+		scopes  []*Scope
+		visited = map[*Scope]bool{}
+		visit   func(scope *Scope)
+	)
+
+	visit = func(scope *Scope) {
+		if visited[scope] {
+			return
+		}
+
+		visited[scope] = true
+		scopes = append(scopes, scope)
+
+		names := make([]string, 0, len(scope.Identifiers))
+		for name := range scope.Identifiers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if imp, ok := scope.Identifiers[name].(*Import); ok {
+				visit(imp.Scope)
+			}
+		}
+	}
+
+	visit(s)
+
+	return scopes
+}
+
+// Finds the methods which can be called through the methods of the interfaces
+// in scopes.  There's no telling which value an interface holds, so calling a
+// method of an interface can throw if any type implementing it has a method
+// which can.
+//
+// Only the types of a module and the modules it imports (including indirectly)
+// can be in an interface.  Interfaces of imported modules are included, since
+// the module's types can implement them.
+func implementationsIn(scopes []*Scope) methodImplementations {
+	var (
+		types  []Type
+		ifaces = []*Interface{errorType}
+	)
+
+	for _, scope := range scopes {
+		names := make([]string, 0, len(scope.Identifiers))
+		for name := range scope.Identifiers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			switch ident := scope.Identifiers[name].(type) {
+			case *Struct, *Enum:
+				types = append(types, ident.(Type))
+			case *Interface:
+				ifaces = append(ifaces, ident)
+			}
+		}
+	}
+
+	impls := methodImplementations{of: map[*Function][]*Function{}}
+
+	for _, iface := range ifaces {
+		for _, typ := range types {
+			if unimplemented(typ, iface) != "" {
+				continue
+			}
+
+			for _, m := range iface.Methods {
+				if _, ok := impls.of[m]; !ok {
+					impls.methods = append(impls.methods, m)
+				}
+
+				impls.of[m] = append(impls.of[m], methodOf(typ, m.Name))
+			}
+		}
+	}
+
+	return impls
+}
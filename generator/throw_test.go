@@ -0,0 +1,53 @@
+package generator
+
+import "testing"
+
+const doers = "public interface Doer {\n\tdo() int\n}\n\npublic func Run(d Doer) int {\n\treturn d.do()\n}\n\npublic func Twice(d Doer) int {\n\treturn Run(d) * 2\n}\n"
+
+const throwingDoer = "import \"ilib\"\n\nstruct E {\n\tmsg string\n}\n\nfunc E.Error() string {\n\treturn this.msg\n}\n\nstruct T {\n\tn int\n}\n\nfunc T.do() int {\n\tthrow E{\"no\"}\n}\n\n"
+
+const doer = "import \"ilib\"\n\nstruct T {\n\tn int\n}\n\nfunc T.do() int {\n\treturn this.n\n}\n\n"
+
+// Calls of interface methods in an imported module can throw if the importing
+// module implements them with methods which can.
+func TestThrowsThroughImportedInterfaces(t *testing.T) {
+	for _, test := range []sourceTest{
+		{
+			name: "uncaught",
+			src:  throwingDoer + "func main() {\n\tilib.Run(T{})\n}\n",
+			err:  "unhandled error: Run() can throw, but main can't",
+		},
+		{
+			name: "uncaught indirectly",
+			src:  throwingDoer + "func main() {\n\tilib.Twice(T{})\n}\n",
+			err:  "unhandled error: Twice() can throw, but main can't",
+		},
+		{
+			name: "uncaught in an initialiser",
+			src:  throwingDoer + "var n = ilib.Run(T{})\n\nfunc main() {\n}\n",
+			err:  "unhandled error: Run() can throw, but variable initialisers can't",
+		},
+		{
+			name: "caught",
+			src:  throwingDoer + "func main() {\n\tn, catch err := ilib.Twice(T{})\n}\n",
+		},
+		{
+			name: "can't throw",
+			src:  doer + "func main() {\n\tilib.Run(T{})\n}\n",
+		},
+		{
+			name: "nothing to catch",
+			src:  doer + "func main() {\n\tn, catch err := ilib.Run(T{})\n}\n",
+			err:  "Run() can't throw, so there's nothing to catch",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// Whether ilib's functions throw depends on the modules importing it,
+			// so each test gets its own.
+			lib := processSource(t, doers)
+			mod := processModule(t, test.src, map[string]*Module{"ilib": &lib})
+
+			checkErrors(t, mod, test.err)
+		})
+	}
+}
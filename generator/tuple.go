@@ -0,0 +1,389 @@
+package generator
+
+import (
+	"fmt"
+	"main/parser"
+	"strings"
+)
+
+// The results of a function which returns more than one value, ie the
+// `(int, string)` of `func f() (int, string)`.  Tuples only exist as the type
+// of calls and returns; they can't be stored.
+type Tuple struct {
+	Types []Type
+}
+
+func (t *Tuple) Kind() Kind {
+	return KindTuple
+}
+
+func (t *Tuple) Name() string {
+	names := make([]string, len(t.Types))
+
+	for i, typ := range t.Types {
+		names[i] = typ.Name()
+	}
+
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func (t *Tuple) Zero() any {
+	return nil
+}
+
+func (t *Tuple) AssignableTo(other Type) bool {
+	if other == nil {
+		return true
+	}
+
+	return identical(t, other)
+}
+
+// Multiple values, ie `return a, b`.
+type TupleValue struct {
+	Values []Typed
+	typ    *Tuple
+}
+
+func (t TupleValue) Type() Type {
+	return t.typ
+}
+
+// The type of a function's results: nil if there aren't any, the result's type
+// if there's one, otherwise a tuple.
+func resultsType(results []*Argument) Type {
+	switch len(results) {
+	case 0:
+		return nil
+	case 1:
+		return results[0].Type()
+	}
+
+	tuple := &Tuple{Types: make([]Type, len(results))}
+
+	for i, result := range results {
+		tuple.Types[i] = result.Type()
+	}
+
+	return tuple
+}
+
+// Whether a and b are the same type.  Types are compared by identity, other
+// than tuples and slices, which are the same if their elements are.
+func identical(a, b Type) bool {
+	switch a := a.(type) {
+	case *Tuple:
+		other, ok := b.(*Tuple)
+
+		if !ok || len(a.Types) != len(other.Types) {
+			return false
+		}
+
+		for i, typ := range a.Types {
+			if !identical(typ, other.Types[i]) {
+				return false
+			}
+		}
+
+		return true
+	case *Slice:
+		other, ok := b.(*Slice)
+		return ok && identical(a.Elem, other.Elem)
+	case *Array:
+		other, ok := b.(*Array)
+		return ok && a.Len == other.Len && identical(a.Elem, other.Elem)
+	}
+
+	return a == b
+}
+
+// Assigns several values at once, ie `a, b = b, a` or `a, b := f()`.  Every
+// value is evaluated before any of them are assigned.
+type AssignTuple struct {
+	// The variables declared by the assignment, which are also targets.
+	Declares []*Variable
+	// A variable, argument or field for each value; nil for `_`.
+	Targets []Writeable
+	// Either a TupleValue, a call returning several values or a TypeAssertion
+	// giving whether it succeeded; nil if the variables are declared without
+	// values, ie `var a, b int`.
+	Value Typed
+	// If Value is a call whose results have to be converted to the types of
+	// their targets (ie boxed into an interface), each result converted, which
+	// refer to the call's results through Results; otherwise nil.
+	Converted []Typed
+}
+
+func (AssignTuple) isStep() {}
+
+// One of the results of the call assigned by an AssignTuple or a Catch, before
+// it's converted to the type of its target.
+type Result struct {
+	Index int
+	typ   Type
+}
+
+func (r Result) Type() Type {
+	return r.typ
+}
+
+// Evaluates the values assigned to n targets: either one value for each, or a
+// single call returning n values.  Returns the value and the type of each of
+// its elements.
+func (s *Scope) evaluateTuple(node parser.AstNode, values []parser.ValueNode, n int) (Typed, []Type) {
+	// Same as go, `a, b := f()` assigns every result of f.
+	if call, ok := values[0].(parser.CallNode); ok && len(values) == 1 && n > 1 && s.typeNamed(call.Callee) == nil {
+		val := s.handleCall(call)
+
+		if val.Target == nil {
+			return nil, nil
+		}
+
+		types := resultTypes(val.Type())
+
+		switch len(types) {
+		case 0:
+			s.error(call, "%s() (no value) used as value", val.Target.Name)
+			return nil, nil
+		case n:
+			return val, types
+		}
+
+		s.error(node, "assignment mismatch: %d variables but %s() returns %s", n, val.Target.Name, plural(len(types), "value"))
+		return nil, nil
+	}
+
+	// `v, ok := a.(T)`
+	if assertion, ok := values[0].(parser.TypeAssertionNode); ok && len(values) == 1 && n == 2 {
+		val, ok := s.evaluateTypeAssertion(assertion).(TypeAssertion)
+
+		if !ok {
+			return nil, nil
+		}
+
+		val.CommaOk = true
+
+		return val, resultTypes(val.Type())
+	}
+
+	if len(values) != n {
+		s.error(node, "assignment mismatch: %s but %s", plural(n, "variable"), plural(len(values), "value"))
+		return nil, nil
+	}
+
+	vals := make([]Typed, n)
+	tuple := &Tuple{Types: make([]Type, n)}
+
+	for i, value := range values {
+		if vals[i] = s.preEvaluate(value); vals[i] == nil {
+			return nil, nil
+		}
+
+		tuple.Types[i] = vals[i].Type()
+	}
+
+	return TupleValue{Values: vals, typ: tuple}, tuple.Types
+}
+
+// ie "1 value" or "2 values".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// Checks the values of step can be assigned to its targets, boxing values
+// assigned to interfaces.  values are the nodes of the values, for errors.
+func (s *Scope) checkTuple(step *AssignTuple, types []Type, values []parser.ValueNode) bool {
+	var (
+		tuple, isValue = step.Value.(TupleValue)
+		converted      = make([]Typed, len(types))
+		converts       = false
+	)
+
+	for i, target := range step.Targets {
+		if target == nil {
+			continue
+		}
+
+		var (
+			node = values[0]
+			typ  = target.Type()
+		)
+
+		if isValue {
+			node = values[i]
+		}
+
+		if !types[i].AssignableTo(typ) {
+			s.error(node, "unable to assign value of type %s to value of type %s%s", types[i].Name(), typ.Name(), unassignable(types[i], typ))
+			return false
+		}
+
+		if isValue {
+			tuple.Values[i] = assignedTo(tuple.Values[i], typ)
+			continue
+		}
+
+		converted[i] = assignedTo(Result{Index: i, typ: types[i]}, typ)
+
+		if _, ok := converted[i].(Result); !ok {
+			converts = true
+		}
+	}
+
+	if converts {
+		// Results assigned to `_` are left as they are.
+		for i, val := range converted {
+			if val == nil {
+				converted[i] = Result{Index: i, typ: types[i]}
+			}
+		}
+
+		step.Converted = converted
+	}
+
+	return true
+}
+
+func (s *Scope) declareTuple(node parser.TupleDeclarationNode) Step {
+	var (
+		typ   Type
+		val   Typed
+		types []Type
+		names = node.Names
+	)
+
+	if node.Type != nil {
+		if typ = s.getType(node.Type); typ == nil {
+			return nil
+		}
+	}
+
+	switch {
+	case node.Catch != nil:
+		call, ok := s.evaluateCaught(node, node.Values, len(names))
+
+		if !ok {
+			return nil
+		}
+
+		// The error is assigned along with the results.
+		val, types = call, append(append([]Type{}, resultTypes(call.Type())...), errorType)
+		names = append(append([]parser.IdentifierNode{}, names...), *node.Catch)
+	case len(node.Values) > 0:
+		if val, types = s.evaluateTuple(node, node.Values, len(names)); val == nil {
+			return nil
+		}
+	}
+
+	step := AssignTuple{
+		Targets: make([]Writeable, len(names)),
+		Value:   val,
+	}
+
+	seen := map[string]bool{}
+
+	for i, name := range names {
+		if name.Target == "_" {
+			continue
+		}
+
+		if seen[name.Target] {
+			s.error(name, "%s repeated on left side of assignment", name.Target)
+			return nil
+		}
+
+		seen[name.Target] = true
+
+		// Same as go, `:=` assigns to variables already declared in the same
+		// scope, as long as it declares at least one new one.
+		if ident, ok := s.Identifiers[name.Target]; ok {
+			v, isVariable := ident.(*Variable)
+
+			if !node.Define || !isVariable {
+				s.error(name, "cannot redeclare identifier '%s'", name.Target)
+				return nil
+			}
+
+			step.Targets[i] = v
+			continue
+		}
+
+		v := &Variable{Name: name.Target, typ: typ}
+
+		if v.typ == nil {
+			if v.typ = s.defaultType(name, types[i]); v.typ == nil {
+				return nil
+			}
+		}
+
+		step.Declares = append(step.Declares, v)
+		step.Targets[i] = v
+	}
+
+	if node.Define && len(step.Declares) == 0 {
+		s.error(node, "no new variables on left side of :=")
+		return nil
+	}
+
+	if val != nil && !s.checkTuple(&step, types, node.Values) {
+		return nil
+	}
+
+	for _, v := range step.Declares {
+		s.Identifiers[v.Name] = v
+	}
+
+	if node.Catch != nil {
+		return caught(step)
+	}
+
+	return step
+}
+
+func (s *Scope) assignTuple(node parser.TupleAssignmentNode) Step {
+	assignees := node.Assignees
+
+	if node.Catch != nil {
+		assignees = append(append([]parser.ValueNode{}, assignees...), node.Catch)
+	}
+
+	step := AssignTuple{Targets: make([]Writeable, len(assignees))}
+
+	for i, assignee := range assignees {
+		if ident, ok := assignee.(parser.IdentifierNode); ok && ident.Target == "_" {
+			continue
+		}
+
+		if step.Targets[i] = s.lookupWriteable(assignee); step.Targets[i] == nil {
+			return nil
+		}
+	}
+
+	var types []Type
+
+	if node.Catch != nil {
+		call, ok := s.evaluateCaught(node, node.Values, len(node.Assignees))
+
+		if !ok {
+			return nil
+		}
+
+		step.Value, types = call, append(append([]Type{}, resultTypes(call.Type())...), errorType)
+	} else if step.Value, types = s.evaluateTuple(node, node.Values, len(assignees)); step.Value == nil {
+		return nil
+	}
+
+	if !s.checkTuple(&step, types, node.Values) {
+		return nil
+	}
+
+	if node.Catch != nil {
+		return caught(step)
+	}
+
+	return step
+}
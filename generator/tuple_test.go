@@ -0,0 +1,89 @@
+package generator
+
+import "testing"
+
+const pair = "func f() (int, string) {\n\treturn 1, \"a\"\n}\n\n"
+
+func TestTuples(t *testing.T) {
+	runSourceTests(t, []sourceTest{
+		{
+			name: "declared from a call",
+			src:  pair + "func main() {\n\ta, b := f()\n\tvar c int = a\n\tvar d string = b\n}\n",
+		},
+		{
+			name: "declared with a type",
+			src:  "func main() {\n\tvar a, b int = 1, 2\n}\n",
+		},
+		{
+			name: "swap",
+			src:  "func main() {\n\ta, b := 1, 2\n\ta, b = b, a\n}\n",
+		},
+		{
+			name: "some declared",
+			src:  "func main() {\n\ta := 1\n\ta, b := 2, 3\n}\n",
+		},
+		{
+			name: "blank",
+			src:  "func main() {\n\t_, b := 1, 2\n}\n",
+		},
+		{
+			name: "named results",
+			src:  "func f() (q, r int) {\n\tq = 1\n\treturn\n}\n",
+		},
+		{
+			name: "too few values",
+			src:  "func main() {\n\ta, b := 1\n}\n",
+			err:  "assignment mismatch: 2 variables but 1 value",
+		},
+		{
+			name: "too few values assigned",
+			src:  "func main() {\n\ta, b := 1, 2\n\ta, b = 1\n}\n",
+			err:  "assignment mismatch: 2 variables but 1 value",
+		},
+		{
+			name: "too many variables for a call",
+			src:  pair + "func main() {\n\ta, b, c := f()\n}\n",
+			err:  "assignment mismatch: 3 variables but f() returns 2 values",
+		},
+		{
+			name: "call in a single-value context",
+			src:  pair + "func main() {\n\ta := f()\n}\n",
+			err:  "multiple-value f() (value of type '(int, string)') in single-value context",
+		},
+		{
+			name: "call in an operation",
+			src:  pair + "func main() {\n\tvar a int = f() + 1\n}\n",
+			err:  "in single-value context",
+		},
+		{
+			name: "wrong type declared",
+			src:  pair + "func main() {\n\tvar a, b int = f()\n}\n",
+			err:  "unable to assign value of type string to value of type int",
+		},
+		{
+			name: "wrong type assigned",
+			src:  "func main() {\n\ta, b := 1, 2\n\ta, b = \"x\", 2\n}\n",
+			err:  "unable to assign value of type string to value of type int",
+		},
+		{
+			name: "nothing declared",
+			src:  "func main() {\n\ta, b := 2, 3\n\ta, b := 4, 5\n}\n",
+			err:  "no new variables on left side of :=",
+		},
+		{
+			name: "too few results",
+			src:  "func f() (int, string) {\n\treturn 1\n}\n",
+			err:  "not enough return values: have (untyped int), want (int, string)",
+		},
+		{
+			name: "too many results",
+			src:  "func f() int {\n\treturn 1, 2\n}\n",
+			err:  "too many return values: have (untyped int, untyped int), want (int)",
+		},
+		{
+			name: "wrong type of result",
+			src:  "func f() (int, string) {\n\treturn 1, 2\n}\n",
+			err:  "invalid return: expected value of type 'string'; received value of type 'untyped int'",
+		},
+	})
+}
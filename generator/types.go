@@ -1,16 +1,21 @@
 package generator
 
+import "main/parser"
+
 type Step interface{ isStep() }
 
 type Assign struct {
-	Target string
+	// A variable, argument or field.
+	Target Writeable
 	Value  Typed
 }
 
 func (Assign) isStep() {}
 
 type Call struct {
-	Target    *Function
+	Target *Function
+	// The value a method was called on; nil for functions.
+	Receiver  Typed
 	Arguments []Typed
 }
 
@@ -20,6 +25,16 @@ func (c Call) Type() Type {
 
 func (Call) isStep() {}
 
+// A conversion of a value to another type, ie `T(x)`.
+type Conversion struct {
+	Value Typed
+	typ   Type
+}
+
+func (c Conversion) Type() Type {
+	return c.typ
+}
+
 type Declare struct {
 	Name string
 	*Variable
@@ -48,6 +63,9 @@ type Argument struct {
 	typ Type
 	// The name of the argument.
 	Name string
+	// Whether the argument is `...T`, which takes any number of values as a
+	// `[]T`.
+	Variadic bool
 }
 
 func (a Argument) Type() Type {
@@ -61,3 +79,42 @@ type Return struct {
 }
 
 func (r Return) isStep() {}
+
+// A `for` loop.  Variables declared by Init live in the loop's own scope.
+type Loop struct {
+	p     Scoped
+	Scope *Scope
+	// Executed once, before the first iteration (if any).
+	Init Step
+	// Checked prior to each iteration; nil if the loop only ends via `break`.
+	Condition Typed
+	// Executed after each iteration, including ones ended by `continue` (if any).
+	Post  Step
+	Block Block
+}
+
+func (l *Loop) parent() Scoped {
+	return l.p
+}
+
+func (l *Loop) lookupIdentifier(node parser.IdentifierNode) any {
+	return l.p.lookupIdentifier(node)
+}
+
+func (l *Loop) Lookup(name string) any {
+	return l.p.Lookup(name)
+}
+
+func (l *Loop) error(node parser.AstNode, str string, rest ...interface{}) {
+	l.p.error(node, str, rest...)
+}
+
+func (*Loop) isStep() {}
+
+type Break struct{}
+
+func (Break) isStep() {}
+
+type Continue struct{}
+
+func (Continue) isStep() {}
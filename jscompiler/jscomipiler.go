@@ -0,0 +1,960 @@
+package jscompiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/generator"
+	"main/inspector"
+	"main/lexer"
+	"strconv"
+	"strings"
+)
+
+// The js names of the top-level declarations of imported modules, which are
+// prefixed by their module's path so they can't clash, ie `factorio$Lamp`.
+var jsNames = map[any]string{}
+
+func jsName(ident any, name string) string {
+	if name, ok := jsNames[ident]; ok {
+		return name
+	}
+
+	return name
+}
+
+func stringifyTyped(val generator.Typed) string {
+	var content strings.Builder
+
+	switch val := val.(type) {
+	case generator.BinaryOperation:
+		if isCopied(val.Left.Type()) {
+			// js compares objects by reference.
+			content.WriteString("JSON.stringify(")
+			content.WriteString(stringifyTyped(val.Left))
+			content.WriteByte(')')
+			content.WriteString(val.Operator.String())
+			content.WriteString("JSON.stringify(")
+			content.WriteString(stringifyTyped(val.Right))
+			content.WriteByte(')')
+			break
+		}
+
+		kind := val.Type().Kind()
+		op := val.Operator.String()
+
+		switch {
+		case val.Operator == lexer.MUL && isInteger32(kind):
+			// A product of 32 bit integers can be too big for a float to hold
+			// exactly.
+			return wrapInteger(kind, "Math.imul("+stringifyTyped(val.Left)+","+stringifyTyped(val.Right)+")")
+		case val.Operator == lexer.RIGHT_SHIFT && isInteger32(kind) && isUnsigned(kind):
+			op = ">>>"
+		case val.Operator == lexer.AND_NOT:
+			op = "&~"
+		}
+
+		if _, ok := val.Left.(generator.BinaryOperation); ok {
+			content.WriteByte('(')
+			content.WriteString(stringifyTyped(val.Left))
+			content.WriteByte(')')
+		} else {
+			content.WriteString(stringifyTyped(val.Left))
+		}
+
+		content.WriteString(op)
+
+		if _, ok := val.Right.(generator.BinaryOperation); ok {
+			content.WriteByte('(')
+			content.WriteString(stringifyTyped(val.Right))
+			content.WriteByte(')')
+		} else {
+			content.WriteString(stringifyTyped(val.Right))
+		}
+
+		if val.Operator == lexer.DIV && (kind == generator.KindInt64 || kind == generator.KindUint64) {
+			return "Math.trunc(" + content.String() + ")"
+		}
+
+		return wrapInteger(kind, content.String())
+	case generator.UnaryOperation:
+		content.WriteString(val.Operator.String())
+		content.WriteByte('(')
+		content.WriteString(stringifyTyped(val.Operand))
+		content.WriteByte(')')
+
+		return wrapInteger(val.Type().Kind(), content.String())
+	case generator.Call:
+		content.WriteString(stringifyCall(val))
+	case *generator.Variable:
+		fmt.Println(inspector.Inspect(val))
+		return jsName(val, val.Name)
+	case *generator.Argument:
+		return val.Name
+	case *generator.Receiver:
+		return "this"
+	case generator.Conversion:
+		return stringifyConversion(val)
+	case generator.FieldAccess:
+		content.WriteString(stringifyTyped(val.Of))
+		content.WriteByte('.')
+		content.WriteString(val.Field.Name)
+	case generator.StructValue:
+		content.WriteByte('{')
+		for i, v := range val.Fields {
+			content.WriteString(val.Type().(*generator.Struct).Fields[i].Name)
+			content.WriteByte(':')
+			content.WriteString(stringifyCopy(v))
+			if i != len(val.Fields)-1 {
+				content.WriteByte(',')
+			}
+		}
+		content.WriteByte('}')
+	case generator.TupleValue:
+		return stringifyList(val.Values)
+	case generator.SliceValue:
+		return "$mk(" + stringifyList(val.Values) + ")"
+	case generator.ArrayValue:
+		return stringifyList(val.Values)
+	case generator.Index:
+		if val.Of.Type().Kind() == generator.KindString {
+			content.WriteString("$sget(")
+		} else {
+			content.WriteString("$get(")
+		}
+		content.WriteString(stringifyTyped(val.Of))
+		content.WriteByte(',')
+		content.WriteString(stringifyTyped(val.Index))
+		content.WriteByte(')')
+	case generator.SliceExpression:
+		if val.Of.Type().Kind() == generator.KindString {
+			content.WriteString("$sslice(")
+		} else {
+			content.WriteString("$slice(")
+		}
+		content.WriteString(stringifyTyped(val.Of))
+		content.WriteByte(',')
+		if val.Low != nil {
+			content.WriteString(stringifyTyped(val.Low))
+		} else {
+			content.WriteByte('0')
+		}
+		if val.High != nil {
+			content.WriteByte(',')
+			content.WriteString(stringifyTyped(val.High))
+		}
+		content.WriteByte(')')
+	case generator.Result:
+		content.WriteString("$r[")
+		content.WriteString(strconv.Itoa(val.Index))
+		content.WriteByte(']')
+	case generator.Length:
+		switch {
+		case val.Capacity:
+			content.WriteString("$cap(")
+		case val.Of.Type().Kind() == generator.KindString:
+			content.WriteString("$slen(")
+		default:
+			content.WriteString("$len(")
+		}
+		content.WriteString(stringifyTyped(val.Of))
+		content.WriteByte(')')
+	case generator.InterfaceValue:
+		content.WriteString("{t:")
+		content.WriteString(stringifyTypeRef(val.Value.Type()))
+		content.WriteString(",v:")
+		content.WriteString(stringifyCopy(val.Value))
+		if isCopied(val.Value.Type()) {
+			content.WriteString(",c:")
+			content.WriteString(stringifyCopier(val.Value.Type()))
+		}
+		content.WriteByte('}')
+	case generator.TypeAssertion:
+		// `v, ok := a.(T)` gives `[v,ok]`.
+		suffix := ""
+		if val.CommaOk {
+			suffix = "Ok"
+		}
+
+		if iface, ok := val.Asserted().(*generator.Interface); ok {
+			content.WriteString("$assertIface" + suffix + "(")
+			content.WriteString(stringifyTyped(val.Value))
+			content.WriteString(",[")
+			for i, m := range iface.Methods {
+				content.WriteString(`"` + m.Name + `"`)
+				if i != len(iface.Methods)-1 {
+					content.WriteByte(',')
+				}
+			}
+			content.WriteString("])")
+			break
+		}
+
+		content.WriteString("$assert" + suffix + "(")
+		content.WriteString(stringifyTyped(val.Value))
+		content.WriteByte(',')
+		content.WriteString(stringifyTypeRef(val.Asserted()))
+		if val.CommaOk {
+			content.WriteByte(',')
+			content.WriteString(stringifyZero(val.Asserted()))
+		}
+		content.WriteByte(')')
+	case generator.Value:
+		if val.Value() == nil {
+			return "null"
+		}
+
+		if str, ok := val.Value().(string); ok {
+			// json strings are valid js strings.
+			buf, _ := json.Marshal(str)
+			content.Write(buf)
+			break
+		}
+
+		content.WriteString(inspector.InspectBland(val.Value()))
+	}
+
+	return content.String()
+}
+
+// Methods are stored on an object named after their type and called with
+// `this` bound to the receiver, ie `T.m.call(a, 1)`.
+func stringifyCall(call generator.Call) string {
+	fn, this, args := callParts(call)
+
+	if this != "" {
+		return fn + ".call(" + strings.Join(append([]string{this}, args...), ",") + ")"
+	}
+
+	return fn + "(" + strings.Join(args, ",") + ")"
+}
+
+// The function called by call, the value of `this` for it (if any) and its
+// arguments.
+func callParts(call generator.Call) (fn, this string, args []string) {
+	switch {
+	case call.Receiver != nil && call.Target.Receiver.Type().Kind() == generator.KindInterface:
+		// The method depends on the type of the value in the interface.
+		fn = "$call"
+		args = append(args, stringifyTyped(call.Receiver), `"`+call.Target.Name+`"`)
+	case call.Receiver != nil:
+		typ := call.Target.Receiver.Type()
+		fn = jsName(typ, typ.Name()) + "." + call.Target.Name
+
+		// Value receivers get a copy, pointer receivers the value itself.
+		if call.Target.Receiver.Ptr {
+			this = stringifyTyped(call.Receiver)
+		} else {
+			this = stringifyCopy(call.Receiver)
+		}
+	case call.Target.Name != "":
+		fn = jsName(call.Target, call.Target.Name)
+	default:
+		fn = "(function" + stringifyArgs(call.Target.Args) + stringifyBlock(call.Target.Steps) + ")"
+	}
+
+	for _, v := range call.Arguments {
+		args = append(args, stringifyCopy(v))
+	}
+
+	return
+}
+
+// `start f(a)` is `queueMicrotask(f.bind(null,a))`, so the arguments are
+// evaluated straight away but f runs once the caller yields.
+func stringifyStart(call generator.Call) string {
+	fn, this, args := callParts(call)
+
+	if this == "" {
+		this = "null"
+	}
+
+	return "queueMicrotask(" + fn + ".bind(" + strings.Join(append([]string{this}, args...), ",") + "));"
+}
+
+// Tuples and slices are both arrays in js.
+func stringifyList(vals []generator.Typed) string {
+	var content strings.Builder
+
+	content.WriteByte('[')
+	for i, v := range vals {
+		content.WriteString(stringifyCopy(v))
+		if i != len(vals)-1 {
+			content.WriteByte(',')
+		}
+	}
+	content.WriteByte(']')
+
+	return content.String()
+}
+
+// Whether values of typ are objects in js but values in tbd: structs and arrays.
+func isCopied(typ generator.Type) bool {
+	return typ.Kind() == generator.KindStruct || typ.Kind() == generator.KindArray
+}
+
+// Structs and arrays are values in tbd but objects in js, so they're copied
+// whenever they'd otherwise be shared.
+func stringifyCopy(val generator.Typed) string {
+	if val != nil && isCopied(val.Type()) {
+		switch val.(type) {
+		case generator.StructValue, generator.ArrayValue, generator.Call:
+		default:
+			return "(" + stringifyCopier(val.Type()) + ")(" + stringifyTyped(val) + ")"
+		}
+	}
+
+	return stringifyTyped(val)
+}
+
+// A function copying a struct or array of typ.  Same as go, only the value
+// itself is copied: a slice in it still shares its elements.
+func stringifyCopier(typ generator.Type) string {
+	var content strings.Builder
+
+	switch typ := typ.(type) {
+	case *generator.Struct:
+		content.WriteString("($v)=>({")
+		for i, field := range typ.Fields {
+			content.WriteString(field.Name)
+			content.WriteByte(':')
+			if isCopied(field.Type) {
+				content.WriteString("(" + stringifyCopier(field.Type) + ")($v." + field.Name + ")")
+			} else {
+				content.WriteString("$v." + field.Name)
+			}
+			if i != len(typ.Fields)-1 {
+				content.WriteByte(',')
+			}
+		}
+		content.WriteString("})")
+	case *generator.Array:
+		if !isCopied(typ.Elem) {
+			return "($v)=>$v.slice()"
+		}
+
+		content.WriteString("($v)=>$v.map(")
+		content.WriteString(stringifyCopier(typ.Elem))
+		content.WriteByte(')')
+	}
+
+	return content.String()
+}
+
+func stringifyZero(typ generator.Type) string {
+	var content strings.Builder
+
+	switch typ := typ.(type) {
+	case *generator.Struct:
+		content.WriteByte('{')
+		for i, field := range typ.Fields {
+			content.WriteString(field.Name)
+			content.WriteByte(':')
+			content.WriteString(stringifyZero(field.Type))
+			if i != len(typ.Fields)-1 {
+				content.WriteByte(',')
+			}
+		}
+		content.WriteByte('}')
+	case *generator.Array:
+		content.WriteByte('[')
+		for i := 0; i < typ.Len; i++ {
+			content.WriteString(stringifyZero(typ.Elem))
+			if i != typ.Len-1 {
+				content.WriteByte(',')
+			}
+		}
+		content.WriteByte(']')
+	case *generator.Interface, *generator.Slice:
+		return "null"
+	default:
+		if typ.Kind() == generator.KindString {
+			return `""`
+		}
+
+		content.WriteString(inspector.InspectBland(typ.Zero()))
+	}
+
+	return content.String()
+}
+
+func stringifyBlock(steps []generator.Step) string {
+	var content strings.Builder
+
+	content.WriteByte('{')
+
+	for _, step := range steps {
+		content.WriteString(stringifyStep(step))
+	}
+
+	content.WriteByte('}')
+
+	return content.String()
+}
+
+func stringifyStep(step generator.Step) string {
+	var content strings.Builder
+
+	switch st := step.(type) {
+	case generator.Declare:
+		content.WriteString("let ")
+		content.WriteString(jsName(st.Variable, st.Name))
+
+		content.WriteByte('=')
+		if st.InitialValue != nil {
+			content.WriteString(stringifyCopy(st.InitialValue))
+		} else {
+			content.WriteString(stringifyZero(st.Type()))
+		}
+		content.WriteByte(';')
+
+	case generator.Assign:
+		content.WriteString(stringifyTarget(st.Target))
+		content.WriteByte('=')
+		content.WriteString(stringifyCopy(st.Value))
+		content.WriteByte(';')
+	case generator.AssignTuple:
+		content.WriteString(stringifyAssignTuple(st))
+	case generator.Start:
+		content.WriteString(stringifyStart(st.Call))
+	case generator.Throw:
+		content.WriteString("throw new $Throw(")
+		content.WriteString(stringifyTyped(st.Value))
+		content.WriteString(");")
+	case generator.Catch:
+		content.WriteString(stringifyCatch(st))
+	case generator.If:
+		content.WriteString("if(")
+		content.WriteString(stringifyTyped(st.Condition))
+		content.WriteByte(')')
+		content.WriteString(stringifyBlock(st.Then.Steps))
+
+		for _, elif := range st.ElseIf {
+			content.WriteString("else if(")
+			content.WriteString(stringifyTyped(elif.Condition))
+			content.WriteByte(')')
+			content.WriteString(stringifyBlock(elif.Then.Steps))
+		}
+
+		if st.Else != nil {
+			content.WriteString("else")
+			content.WriteString(stringifyBlock(st.Else.Steps))
+		}
+
+	case generator.Block:
+		content.WriteString("if(1)")
+		content.WriteString(stringifyBlock(st.Steps))
+	case generator.Call:
+		content.WriteString(stringifyCall(st))
+		content.WriteByte(';')
+	case generator.Return:
+		content.WriteString("return ")
+		content.WriteString(stringifyCopy(st.Value))
+	case *generator.Loop:
+		// the init statement is scoped to the loop, same as in tbd.
+		content.WriteByte('{')
+		if st.Init != nil {
+			content.WriteString(stringifyStep(st.Init))
+		}
+
+		content.WriteString("for(;")
+		if st.Condition != nil {
+			content.WriteString(stringifyTyped(st.Condition))
+		}
+		content.WriteByte(';')
+		if st.Post != nil {
+			content.WriteString(strings.TrimSuffix(stringifyStep(st.Post), ";"))
+		}
+		content.WriteByte(')')
+		content.WriteString(stringifyBlock(st.Block.Steps))
+		content.WriteByte('}')
+	case *generator.Switch:
+		content.WriteString(stringifySwitch(st))
+	case generator.Break:
+		content.WriteString("break;")
+	case generator.Continue:
+		content.WriteString("continue;")
+	}
+
+	return content.String()
+}
+
+// Switches are native, ie `switch(x){case 1:case 2:{..}break;default:{..}}`.
+// Tagless switches match each case against true, and structs and arrays are
+// compared as json, same as with `==`.
+func stringifySwitch(st *generator.Switch) string {
+	var content strings.Builder
+
+	compare := func(val generator.Typed) string {
+		switch {
+		case st.Tag == nil:
+			return "!!(" + stringifyTyped(val) + ")"
+		case isCopied(st.Tag.Type()):
+			return "JSON.stringify(" + stringifyTyped(val) + ")"
+		}
+
+		return stringifyTyped(val)
+	}
+
+	// the init statement is scoped to the switch, same as in tbd.
+	content.WriteByte('{')
+	if st.Init != nil {
+		content.WriteString(stringifyStep(st.Init))
+	}
+
+	content.WriteString("switch(")
+	if st.Tag != nil {
+		content.WriteString(compare(st.Tag))
+	} else {
+		content.WriteString("true")
+	}
+	content.WriteString("){")
+
+	for _, c := range st.Cases {
+		if c.Values == nil {
+			content.WriteString("default:")
+		}
+
+		for _, val := range c.Values {
+			content.WriteString("case ")
+			content.WriteString(compare(val))
+			content.WriteByte(':')
+		}
+
+		content.WriteString(stringifyBlock(c.Block.Steps))
+
+		if !c.Fallthrough {
+			content.WriteString("break;")
+		}
+	}
+
+	content.WriteString("}}")
+
+	return content.String()
+}
+
+// Tuple assignments are destructured, ie `let [a,b]=f();` or `[a,b]=[b,a];`.
+func stringifyAssignTuple(st generator.AssignTuple) string {
+	var content strings.Builder
+
+	if st.Value == nil {
+		// `var a, b int`
+		for _, v := range st.Declares {
+			content.WriteString(stringifyStep(generator.Declare{Name: v.Name, Variable: v}))
+		}
+
+		return content.String()
+	}
+
+	declares := len(st.Declares) == len(st.Targets)
+
+	if declares {
+		content.WriteString("let ")
+	} else if len(st.Declares) > 0 {
+		content.WriteString("let ")
+		for i, v := range st.Declares {
+			content.WriteString(jsName(v, v.Name))
+			if i != len(st.Declares)-1 {
+				content.WriteByte(',')
+			}
+		}
+		content.WriteByte(';')
+	}
+
+	var operands targetOperands
+	var assign strings.Builder
+
+	assign.WriteByte('[')
+	for i, target := range st.Targets {
+		// `_` is left as a hole.
+		if target != nil {
+			assign.WriteString(operands.target(target))
+		}
+		if i != len(st.Targets)-1 {
+			assign.WriteByte(',')
+		}
+	}
+	assign.WriteString("]=")
+
+	if st.Converted != nil {
+		assign.WriteString(stringifyConverted(stringifyTyped(st.Value), st.Converted, true))
+	} else {
+		assign.WriteString(stringifyTyped(st.Value))
+	}
+
+	if len(operands.params) == 0 {
+		content.WriteString(assign.String())
+	} else {
+		content.WriteString("((" + strings.Join(operands.params, ",") + ")=>" + assign.String() + ")(")
+		content.WriteString(strings.Join(operands.args, ","))
+		content.WriteByte(')')
+	}
+
+	content.WriteByte(';')
+
+	return content.String()
+}
+
+// The operands of the indexes in the targets of a tuple assignment.  Same as go,
+// they're evaluated before any of the targets are assigned, so `i, a[i] = 2, 9`
+// assigns to the element at the old i.  They're passed to the assignment as
+// `$0`, `$1`... ie `(($0)=>[i,$el(a,$0).v]=[2,9])(i)`.
+type targetOperands struct {
+	params, args []string
+}
+
+func (o *targetOperands) operand(val generator.Typed) string {
+	if _, ok := val.(generator.ConstantValue); ok {
+		return stringifyTyped(val)
+	}
+
+	param := "$" + strconv.Itoa(len(o.params))
+	o.params = append(o.params, param)
+	o.args = append(o.args, stringifyTyped(val))
+
+	return param
+}
+
+func (o *targetOperands) target(target generator.Typed) string {
+	switch target := target.(type) {
+	case generator.Index:
+		// Slices refer to their elements, but arrays are assigned to in place,
+		// so `a, a[0] = b, 1` assigns to the new a.
+		of := o.operand
+		if target.Of.Type().Kind() == generator.KindArray {
+			of = o.target
+		}
+
+		return "$el(" + of(target.Of) + "," + o.operand(target.Index) + ").v"
+	case generator.FieldAccess:
+		return o.target(target.Of) + "." + target.Field.Name
+	}
+
+	return stringifyTyped(target)
+}
+
+// The results of call converted to the types of their targets.  The results
+// are passed to a function as `$r` (wrapped in a list if there's only one), so
+// the call is still only made once.
+func stringifyConverted(call string, converted []generator.Typed, tuple bool) string {
+	if !tuple {
+		return "(($r)=>(" + stringifyTyped(converted[0]) + "))([" + call + "])"
+	}
+
+	return "(($r)=>" + stringifyList(converted) + ")(" + call + ")"
+}
+
+// `a, catch err = f()` is `try{a=f();err=null}catch($e){...;err=$e.e}`.  Any
+// variables it declares are declared first, so they're in scope after it.
+func stringifyCatch(st generator.Catch) string {
+	var content strings.Builder
+
+	for _, v := range st.Declares {
+		content.WriteString(stringifyStep(generator.Declare{Name: v.Name, Variable: v}))
+	}
+
+	content.WriteString("try{")
+
+	switch {
+	case len(st.Targets) == 1 && st.Targets[0] != nil:
+		content.WriteString(stringifyTarget(st.Targets[0]))
+		content.WriteByte('=')
+	case len(st.Targets) > 1:
+		content.WriteByte('[')
+		for i, target := range st.Targets {
+			if target != nil {
+				content.WriteString(stringifyTarget(target))
+			}
+			if i != len(st.Targets)-1 {
+				content.WriteByte(',')
+			}
+		}
+		content.WriteString("]=")
+	}
+
+	if st.Converted != nil {
+		content.WriteString(stringifyConverted(stringifyCall(st.Call), st.Converted, len(st.Converted) > 1))
+	} else {
+		content.WriteString(stringifyCall(st.Call))
+	}
+
+	content.WriteByte(';')
+
+	if st.Error != nil {
+		content.WriteString(stringifyTarget(st.Error))
+		content.WriteString("=null;")
+	}
+
+	content.WriteString("}catch($e){if(!($e instanceof $Throw))throw $e;")
+
+	if st.Error != nil {
+		content.WriteString(stringifyTyped(st.Error))
+		content.WriteString("=$e.e;")
+	}
+
+	content.WriteByte('}')
+
+	return content.String()
+}
+
+func stringifyArgs(args []*generator.Argument) string {
+	var content strings.Builder
+
+	content.WriteByte('(')
+	for i, arg := range args {
+		content.WriteString(arg.Name)
+		if i != len(args)-1 {
+			content.WriteByte(',')
+		}
+	}
+	content.WriteByte(')')
+
+	return content.String()
+}
+
+// Numbers are all floats in js, so converting one to an integer type truncates
+// it and wraps it around to the type's size.  Enums are numbers too, so they
+// don't need converting.
+func stringifyConversion(c generator.Conversion) string {
+	val := stringifyTyped(c.Value)
+	from := c.Value.Type().Kind()
+
+	// Widening a number doesn't change it.
+	if c.Value.Type().AssignableTo(c.Type()) {
+		return val
+	}
+
+	switch c.Type().Kind() {
+	case generator.KindString:
+		return "$rune(" + val + ")"
+	case generator.KindFloat32:
+		return "Math.fround(" + val + ")"
+	case generator.KindInt64, generator.KindUint64:
+		if from == generator.KindFloat32 || from == generator.KindFloat64 {
+			return "Math.trunc(" + val + ")"
+		}
+
+		return val
+	}
+
+	return wrapInteger(c.Type().Kind(), val)
+}
+
+// Integers are js numbers, so same as go, val is wrapped around to the size of
+// kind (and truncated, for divisions) if it's a 32 bit or smaller integer.
+// Otherwise it's left as is; 64 bit integers only hold what a float can.
+func wrapInteger(kind generator.Kind, val string) string {
+	switch kind {
+	case generator.KindInt8:
+		return "((" + val + ")<<24>>24)"
+	case generator.KindInt16:
+		return "((" + val + ")<<16>>16)"
+	case generator.KindInt, generator.KindInt32:
+		return "((" + val + ")|0)"
+	case generator.KindUint8:
+		return "((" + val + ")&255)"
+	case generator.KindUint16:
+		return "((" + val + ")&65535)"
+	case generator.KindUint, generator.KindUint32:
+		return "((" + val + ")>>>0)"
+	}
+
+	return val
+}
+
+func isInteger32(kind generator.Kind) bool {
+	switch kind {
+	case generator.KindInt8, generator.KindInt16, generator.KindInt, generator.KindInt32,
+		generator.KindUint8, generator.KindUint16, generator.KindUint, generator.KindUint32:
+		return true
+	}
+
+	return false
+}
+
+func isUnsigned(kind generator.Kind) bool {
+	switch kind {
+	case generator.KindUint8, generator.KindUint16, generator.KindUint, generator.KindUint32, generator.KindUint64:
+		return true
+	}
+
+	return false
+}
+
+// Integers which aren't valid code points are converted to "\uFFFD", same as
+// go.
+const conversionRuntime = `function $rune(c){return c>=0&&c<=0x10ffff&&!(c>=0xd800&&c<0xe000)?String.fromCodePoint(c):"\ufffd"}`
+
+// Something which can be assigned to.  Elements of arrays and slices are
+// assigned through an object whose `v` refers to the element, so the array and
+// index are only evaluated once, even in `[a, $el(b, i).v] = f()`.
+func stringifyTarget(target generator.Writeable) string {
+	if index, ok := target.(generator.Index); ok {
+		return "$el(" + stringifyTyped(index.Of) + "," + stringifyTyped(index.Index) + ").v"
+	}
+
+	return stringifyTyped(target)
+}
+
+// Arrays are js arrays, and slices are `{a, o, l, c}`: the array they refer to,
+// the index in it of their first element, their length and their capacity.
+// nil slices are null.
+const sliceRuntime = `function $mk(a){return{a,o:0,l:a.length,c:a.length}}` +
+	`function $len(s){return s===null?0:Array.isArray(s)?s.length:s.l}` +
+	`function $cap(s){return s===null?0:Array.isArray(s)?s.length:s.c}` +
+	`function $idx(i,n){if(!(i>=0&&i<n))throw new Error("index out of range ["+i+"] with length "+n);return i}` +
+	`function $get(s,i){i=$idx(i,$len(s));return Array.isArray(s)?s[i]:s.a[s.o+i]}` +
+	`function $el(s,i){i=$idx(i,$len(s));const a=Array.isArray(s)?s:s.a,o=Array.isArray(s)?i:s.o+i;return{get v(){return a[o]},set v(x){a[o]=x}}}` +
+	`function $slice(s,l,h=$len(s)){const c=$cap(s);if(!(0<=l&&l<=h&&h<=c))throw new Error("slice bounds out of range ["+l+":"+h+"] with capacity "+c);` +
+	`return s===null?null:Array.isArray(s)?{a:s,o:l,l:h-l,c:c-l}:{a:s.a,o:s.o+l,l:h-l,c:c-l}}`
+
+// Strings are js strings, but same as go, their lengths, indexes and slices are
+// in bytes of their UTF-8 encoding.  js strings can't hold invalid UTF-8, so
+// slicing in the middle of a code point gives "\uFFFD" for its bytes instead.
+const stringRuntime = `const $utf8=new TextEncoder(),$utf16=new TextDecoder();` +
+	`function $slen(s){return $utf8.encode(s).length}` +
+	`function $sget(s,i){const b=$utf8.encode(s);return b[$idx(i,b.length)]}` +
+	`function $sslice(s,l,h){const b=$utf8.encode(s);if(h===undefined)h=b.length;` +
+	`if(!(0<=l&&l<=h&&h<=b.length))throw new Error("slice bounds out of range ["+l+":"+h+"] with length "+b.length);return $utf16.decode(b.subarray(l,h))}`
+
+// Values in interfaces are stored as `{t, v}`: the object holding the methods
+// of the value's type (or the type's name if it has none), and the value.
+// Structs and arrays also have `c`, their copier, since they're copied when
+// they're used.
+const interfaceRuntime = `function $val(i){return i.c?i.c(i.v):i.v}` +
+	`function $call(i,m,...a){return i.t[m].call($val(i),...a)}` +
+	`function $assert(i,t){if(i===null||i.t!==t)throw new Error("interface conversion failed");return $val(i)}` +
+	`function $assertIface(i,ms){if(i===null||typeof i.t!=="object"||!ms.every(m=>m in i.t))throw new Error("interface conversion failed");return i}` +
+	`function $assertOk(i,t,z){return i!==null&&i.t===t?[$val(i),true]:[z,false]}` +
+	`function $assertIfaceOk(i,ms){return i!==null&&typeof i.t==="object"&&ms.every(m=>m in i.t)?[i,true]:[null,false]}`
+
+// Thrown errors are wrapped so `catch` can tell them apart from js errors, which
+// keep propagating.
+const throwRuntime = `class $Throw{constructor(e){this.e=e}}`
+
+// The reference to typ stored in interfaces.
+func stringifyTypeRef(typ generator.Type) string {
+	switch typ.(type) {
+	case *generator.Struct, *generator.Enum:
+		return jsName(typ, typ.Name())
+	}
+
+	buf, _ := json.Marshal(typ.Name())
+	return string(buf)
+}
+
+// The object holding the methods of a struct or enum; owner is nil for enums.
+func stringifyMethods(name string, methods []*generator.Function, owner *generator.Struct) string {
+	var content strings.Builder
+
+	content.WriteString("const ")
+	content.WriteString(name)
+	content.WriteString("={")
+
+	for _, fn := range methods {
+		content.WriteString(fn.Name)
+		content.WriteString(stringifyArgs(fn.Args))
+		content.WriteString(stringifyBlock(fn.Steps))
+		content.WriteByte(',')
+	}
+
+	// Only used by interfaces; calls on the struct itself go straight to the
+	// embedded field.
+	if owner != nil {
+		for _, m := range owner.Promoted() {
+			content.WriteString(m.Name)
+			content.WriteString("(...a){return ")
+			typ := m.Receiver.Type()
+			content.WriteString(jsName(typ, typ.Name()))
+			content.WriteByte('.')
+			content.WriteString(m.Name)
+			content.WriteString(".call(this")
+
+			of := owner
+			for _, index := range m.Path {
+				field := of.Fields[index]
+				content.WriteByte('.')
+				content.WriteString(field.Name)
+				of, _ = field.Type.(*generator.Struct)
+			}
+
+			content.WriteString(",...a)},")
+		}
+	}
+
+	content.WriteString("};")
+
+	return content.String()
+}
+
+// Stringify compiles the modules into a single JavaScript program. Imported
+// modules are emitted before the modules which import them (the order
+// they're loaded in).
+func Stringify(mods []*generator.Module) string {
+	var content strings.Builder
+
+	// Otherwise `this` is boxed when methods are called on numbers (enums).
+	content.WriteString(`"use strict";`)
+	content.WriteString(interfaceRuntime)
+	content.WriteString(throwRuntime)
+	content.WriteString(conversionRuntime)
+	content.WriteString(sliceRuntime)
+	content.WriteString(stringRuntime)
+
+	for _, mod := range mods {
+		if mod.Path == "main" {
+			continue
+		}
+
+		prefix := strings.NewReplacer("/", "$", ".", "$", "-", "$").Replace(mod.Path) + "$"
+
+		for name, ident := range mod.Identifiers {
+			switch ident.(type) {
+			case *generator.Function, *generator.Variable, *generator.Struct, *generator.Enum:
+				jsNames[ident] = prefix + name
+			}
+		}
+	}
+
+	for _, mod := range mods {
+		content.WriteString(stringifyModule(mod))
+	}
+
+	return content.String()
+}
+
+func stringifyModule(mod *generator.Module) string {
+	var content strings.Builder
+
+	// Every struct and enum gets an object holding its methods, which is also
+	// how interfaces tell types apart.
+	for name, ident := range mod.Scope.Identifiers {
+		switch typ := ident.(type) {
+		case *generator.Struct:
+			content.WriteString(stringifyMethods(jsName(typ, name), typ.Methods, typ))
+		case *generator.Enum:
+			content.WriteString(stringifyMethods(jsName(typ, name), typ.Methods, nil))
+		}
+	}
+
+	for _, step := range mod.Declarations {
+		content.WriteString(stringifyStep(step))
+	}
+
+	for name, ident := range mod.Scope.Identifiers {
+		fn, ok := ident.(*generator.Function)
+
+		if !ok {
+			continue
+		}
+
+		content.WriteString("function ")
+		content.WriteString(jsName(fn, name))
+		content.WriteString(stringifyArgs(fn.Args))
+
+		content.WriteString(stringifyBlock(fn.Steps))
+	}
+
+	// content.WriteString("main();")
+
+	return content.String()
+}
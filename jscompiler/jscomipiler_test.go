@@ -0,0 +1,179 @@
+package jscompiler
+
+import (
+	"main/loader"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Compiles src as the main module and runs it with node, returning what
+// `main()` returns.
+func runJS(t *testing.T, src string) string {
+	t.Helper()
+
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node isn't installed")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tbd")
+
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mods, errs := loader.New(dir).Load(path)
+
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(dir, "main.js")
+	js := Stringify(mods) + "console.log(main())\n"
+
+	if err := os.WriteFile(script, []byte(js), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(node, script).CombinedOutput()
+
+	if err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+type jsTest struct {
+	name string
+	src  string
+	want string
+}
+
+func runJSTests(t *testing.T, tests []jsTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := runJS(t, test.src); got != test.want {
+				t.Errorf("main() = %s; want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	runJSTests(t, []jsTest{
+		{
+			name: "division truncates",
+			src:  "func main() int {\n\ta, b := 7, 2\n\treturn a / b\n}\n",
+			want: "3",
+		},
+		{
+			name: "negative division truncates towards zero",
+			src:  "func main() int {\n\ta, b := -7, 2\n\treturn a / b\n}\n",
+			want: "-3",
+		},
+		{
+			name: "int64 division truncates",
+			src:  "func main() int64 {\n\tvar a, b int64 = 7, 2\n\treturn a / b\n}\n",
+			want: "3",
+		},
+		{
+			name: "int8 increment wraps",
+			src:  "func main() int8 {\n\tvar a int8 = 127\n\ta++\n\treturn a\n}\n",
+			want: "-128",
+		},
+		{
+			name: "uint8 subtraction wraps",
+			src:  "func main() uint8 {\n\tvar a uint8 = 3\n\ta -= 5\n\treturn a\n}\n",
+			want: "254",
+		},
+		{
+			name: "int multiplication wraps",
+			src:  "func main() int {\n\ta := 65537\n\treturn a * a\n}\n",
+			want: "131073",
+		},
+		{
+			name: "uint32 shift is unsigned",
+			src:  "func main() uint32 {\n\tvar a uint32 = 4294967295\n\treturn a >> 1\n}\n",
+			want: "2147483647",
+		},
+		{
+			name: "negation wraps",
+			src:  "func main() int8 {\n\tvar a int8 = -128\n\treturn -a\n}\n",
+			want: "-128",
+		},
+		{
+			name: "and not",
+			src:  "func main() uint16 {\n\tvar a, b uint16 = 12, 4\n\treturn a &^ b\n}\n",
+			want: "8",
+		},
+	})
+}
+
+func TestTupleAssignmentOrder(t *testing.T) {
+	runJSTests(t, []jsTest{
+		{
+			name: "slice index",
+			src:  "func main() int {\n\tarr := []int{1, 2, 3}\n\ti := 0\n\ti, arr[i] = 2, 9\n\treturn arr[0]*100 + arr[1]*10 + arr[2]\n}\n",
+			want: "923",
+		},
+		{
+			name: "array index",
+			src:  "func main() int {\n\tarr := [3]int{1, 2, 3}\n\ti := 0\n\ti, arr[i] = 2, 9\n\treturn arr[0]*100 + arr[1]*10 + arr[2]\n}\n",
+			want: "923",
+		},
+		{
+			name: "field of element",
+			src:  "struct T {\n\tx int\n}\n\nfunc main() int {\n\tts := []T{T{1}, T{2}}\n\ti := 0\n\ti, ts[i].x = 1, 9\n\treturn ts[0].x*10 + ts[1].x\n}\n",
+			want: "92",
+		},
+		{
+			name: "swap",
+			src:  "func main() int {\n\tarr := []int{1, 2}\n\tarr[0], arr[1] = arr[1], arr[0]\n\treturn arr[0]*10 + arr[1]\n}\n",
+			want: "21",
+		},
+		{
+			name: "reassigned slice",
+			src:  "func main() int {\n\ta, b := []int{1}, []int{2}\n\tc := a\n\ta, a[0] = b, 9\n\treturn c[0]*10 + a[0]\n}\n",
+			want: "92",
+		},
+		{
+			name: "reassigned array",
+			src:  "func main() int {\n\ta, b := [1]int{1}, [1]int{2}\n\ta, a[0] = b, 9\n\treturn a[0]*10 + b[0]\n}\n",
+			want: "92",
+		},
+	})
+}
+
+func TestTypeAssertions(t *testing.T) {
+	const shapes = "interface shape {\n\tarea() int\n}\n\ninterface named {\n\tname() string\n}\n\nstruct square {\n\tn int\n}\n\nfunc square.area() int {\n\treturn this.n * this.n\n}\n\nstruct circle {\n\tr int\n}\n\nfunc circle.area() int {\n\treturn 3 * this.r * this.r\n}\n\n"
+
+	runJSTests(t, []jsTest{
+		{
+			name: "comma ok",
+			src:  shapes + "func main() int {\n\tvar s shape = square{2}\n\tq, ok := s.(square)\n\tif !ok {\n\t\treturn -1\n\t}\n\treturn q.n\n}\n",
+			want: "2",
+		},
+		{
+			name: "comma ok failure gives the zero value",
+			src:  shapes + "func main() int {\n\tvar s shape = square{2}\n\tc, ok := s.(circle)\n\tif ok {\n\t\treturn -1\n\t}\n\treturn c.r\n}\n",
+			want: "0",
+		},
+		{
+			name: "comma ok of nil",
+			src:  shapes + "func main() bool {\n\tvar s shape\n\t_, ok := s.(square)\n\treturn ok\n}\n",
+			want: "false",
+		},
+		{
+			name: "comma ok interface",
+			src:  shapes + "func main() int {\n\tvar s shape = circle{1}\n\tn := 0\n\tif _, ok := s.(named); !ok {\n\t\tn += 10\n\t}\n\tif a, ok := s.(shape); ok {\n\t\tn += a.area()\n\t}\n\treturn n\n}\n",
+			want: "13",
+		},
+	})
+}
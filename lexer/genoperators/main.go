@@ -0,0 +1,278 @@
+// Generates lexer/operators.go from lexer/operators.yaml.  Run via `go generate ./lexer`.
+//
+// The yaml is checked for consistency before anything is written: names and strings have to be unique (including
+// the strings of assignment forms), and no two operators may share a slot in the scanner's lookup table.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+type operator struct {
+	Name       string
+	String     string `yaml:"string"`
+	Unary      bool   `yaml:"unary"`
+	Assignment bool   `yaml:"assignment"`
+	Precedence int    `yaml:"precedence"`
+}
+
+func (op operator) assignmentName() string { return op.Name + "_ASSIGN" }
+
+func main() {
+	in, out := "operators.yaml", "operators.go"
+	if len(os.Args) == 3 {
+		in, out = os.Args[1], os.Args[2]
+	}
+
+	src, err := os.ReadFile(in)
+	if err != nil {
+		fail(err)
+	}
+
+	code, err := render(src)
+	if err != nil {
+		fail(fmt.Errorf("%s: %w", in, err))
+	}
+
+	if err := os.WriteFile(out, code, 0o644); err != nil {
+		fail(err)
+	}
+}
+
+// The formatted operators.go for the yaml src.
+func render(src []byte) ([]byte, error) {
+	ops, err := parseOperators(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkOperators(ops); err != nil {
+		return nil, err
+	}
+
+	code, err := format.Source(generate(ops))
+	if err != nil {
+		return nil, fmt.Errorf("unable to format generated code: %w", err)
+	}
+
+	return code, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "genoperators:", err)
+	os.Exit(1)
+}
+
+// The order of the yaml is kept (it decides the order of the tokens), so the document is walked as a node rather than
+// decoded into a map.
+func parseOperators(src []byte) ([]operator, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, errors.New("no operators defined")
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of operator names", root.Line)
+	}
+
+	ops := make([]operator, 0, len(root.Content)/2)
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		op := operator{Name: key.Value}
+		if err := value.Decode(&op); err != nil {
+			return nil, fmt.Errorf("%s: %w", op.Name, err)
+		}
+
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+func checkOperators(ops []operator) error {
+	names := make(map[string]bool)
+	strs := make(map[string]string)
+	// Mirrors the scanner's operatorLookupTable: the first character, plus the second one shifted by 7.
+	slots := make(map[int]string)
+
+	define := func(name, str string) error {
+		if names[name] {
+			return fmt.Errorf("%s is defined more than once", name)
+		}
+		names[name] = true
+
+		if other, ok := strs[str]; ok {
+			return fmt.Errorf("%s and %s are both '%s'", other, name, str)
+		}
+		strs[str] = name
+
+		return nil
+	}
+
+	for _, op := range ops {
+		if !isTokenName(op.Name) {
+			return fmt.Errorf("%q is not a valid token name", op.Name)
+		}
+
+		if op.String == "" {
+			return fmt.Errorf("%s has no string", op.Name)
+		}
+
+		for _, c := range op.String {
+			if c > unicode.MaxASCII || unicode.IsLetter(c) || unicode.IsDigit(c) || unicode.IsSpace(c) {
+				return fmt.Errorf("%s: '%s' can't be scanned as an operator", op.Name, op.String)
+			}
+		}
+
+		if op.Precedence < 0 {
+			return fmt.Errorf("%s has a negative precedence", op.Name)
+		}
+
+		if err := define(op.Name, op.String); err != nil {
+			return err
+		}
+
+		if op.Assignment {
+			if err := define(op.assignmentName(), op.String+"="); err != nil {
+				return err
+			}
+		}
+
+		if slot, ok := lookupSlot(op.String); ok {
+			if other, taken := slots[slot]; taken {
+				return fmt.Errorf("%s and %s share a lookup slot", other, op.Name)
+			}
+			slots[slot] = op.Name
+		}
+	}
+
+	return nil
+}
+
+func isTokenName(name string) bool {
+	for i, c := range name {
+		if !('A' <= c && c <= 'Z') && c != '_' && (i == 0 || !('0' <= c && c <= '9')) {
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+// The index of str in operatorLookupTable.  Operators longer than two characters are read by the scanner itself.
+func lookupSlot(str string) (int, bool) {
+	switch len(str) {
+	case 1:
+		return int(str[0]), true
+	case 2:
+		return int(str[0]) | int(str[1])<<7, true
+	}
+
+	return 0, false
+}
+
+func generate(ops []operator) []byte {
+	var b bytes.Buffer
+	p := func(format string, args ...any) { fmt.Fprintf(&b, format+"\n", args...) }
+
+	p("// Code generated by genoperators from operators.yaml; DO NOT EDIT.")
+	p("")
+	p("package lexer")
+	p("")
+	p("// Operators and delimiters")
+	p("const (")
+	p("operators_begin Token = values_end + 1 + iota")
+	p("")
+	for _, op := range ops {
+		p("%s // %s", op.Name, op.String)
+	}
+	p("")
+	p("assignment_operators_begin")
+	for _, op := range ops {
+		if op.Assignment {
+			p("%s // %s=", op.assignmentName(), op.String)
+		}
+	}
+	p("assignment_operators_end")
+	p("")
+	p("operators_end")
+	p(")")
+	p("")
+
+	p("var (")
+	p("operators = [...]string{")
+	for _, op := range ops {
+		p("%s: %q,", op.Name, op.String)
+		if op.Assignment {
+			p("%s: %q,", op.assignmentName(), op.String+"=")
+		}
+	}
+	p("}")
+	p("")
+
+	p("operatorLookupTable = [...]Token{")
+	for _, op := range ops {
+		if _, ok := lookupSlot(op.String); !ok {
+			continue
+		}
+
+		if len(op.String) == 1 {
+			p("%q: %s,", op.String[0], op.Name)
+		} else {
+			p("(%q | (%q << 7)): %s,", op.String[0], op.String[1], op.Name)
+		}
+	}
+	p("}")
+	p("")
+
+	p("assignmentOperators = [...]Token{")
+	for _, op := range ops {
+		if op.Assignment {
+			p("%s: %s,", op.Name, op.assignmentName())
+		}
+	}
+	p("}")
+	p("")
+
+	p("nonAssignmentOperators = [...]Token{")
+	for _, op := range ops {
+		if op.Assignment {
+			p("%s: %s,", op.assignmentName(), op.Name)
+		}
+	}
+	p("}")
+	p("")
+
+	p("unaryOperators = [...]bool{")
+	for _, op := range ops {
+		if op.Unary {
+			p("%s: true,", op.Name)
+		}
+	}
+	p("}")
+	p("")
+
+	p("precedences = [...]int{")
+	for _, op := range ops {
+		if op.Precedence > 0 {
+			p("%s: %d,", op.Name, op.Precedence)
+		}
+	}
+	p("}")
+	p(")")
+
+	return b.Bytes()
+}
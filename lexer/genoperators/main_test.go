@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// operators.go has to be regenerated whenever operators.yaml changes.
+func TestGeneratedOperatorsUpToDate(t *testing.T) {
+	src, err := os.ReadFile("../operators.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := render(src)
+	if err != nil {
+		t.Fatalf("operators.yaml: %s", err)
+	}
+
+	got, err := os.ReadFile("../operators.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatal("lexer/operators.go doesn't match operators.yaml; run `go generate ./lexer`")
+	}
+}
+
+func TestCheckOperators(t *testing.T) {
+	for _, test := range []struct {
+		name, yaml string
+	}{
+		{"duplicate string", "A:\n  string: \"+\"\nB:\n  string: \"+\"\n"},
+		{"duplicate assignment form", "A:\n  string: \"+\"\n  assignment: true\nB:\n  string: \"+=\"\n"},
+		{"invalid name", "a:\n  string: \"+\"\n"},
+		{"missing string", "A:\n  unary: true\n"},
+		{"letter in string", "A:\n  string: \"a\"\n"},
+		{"negative precedence", "A:\n  string: \"+\"\n  precedence: -1\n"},
+	} {
+		if _, err := render([]byte(test.yaml)); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
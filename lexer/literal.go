@@ -0,0 +1,138 @@
+package lexer
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Unquote returns the value of a STRING or CHAR literal as returned by the
+// scanner, ie `"a\tb"` -> "a	b".
+//
+// Raw strings (`{string}`) are returned as-is, minus carriage returns.  Other
+// literals may contain the following escape sequences:
+//
+//	\n \t \r \0  newline, tab, carriage return and null
+//	\\ \" \'     backslash, and the literal's quote
+//	\xNN         a single byte, given as two hexadecimal digits
+//	\u{N...}     a unicode code point, given as one to six hexadecimal digits
+func Unquote(raw string) (string, error) {
+	if len(raw) < 2 || raw[0] != raw[len(raw)-1] {
+		return "", errors.New("invalid literal")
+	}
+
+	quote, body := raw[0], raw[1:len(raw)-1]
+
+	if quote == '`' {
+		return strings.ReplaceAll(body, "\r", ""), nil
+	}
+
+	var str strings.Builder
+
+	for body != "" {
+		value, isByte, tail, err := unquoteChar(body, quote)
+
+		if err != nil {
+			return "", err
+		}
+
+		if isByte {
+			str.WriteByte(byte(value))
+		} else {
+			str.WriteRune(value)
+		}
+
+		body = tail
+	}
+
+	return str.String(), nil
+}
+
+// UnquoteChar returns the value of a CHAR literal as returned by the scanner,
+// ie `'a'` -> 'a'.  Same as go, a byte escape gives the byte's value, so
+// `'\xff'` is 255 rather than the code point its UTF-8 encoding would decode
+// to.
+func UnquoteChar(raw string) (rune, error) {
+	if len(raw) < 2 || raw[0] != '\'' || raw[len(raw)-1] != '\'' {
+		return 0, errors.New("invalid literal")
+	}
+
+	body := raw[1 : len(raw)-1]
+
+	if body == "" {
+		return 0, errors.New("rune literal must contain exactly one character")
+	}
+
+	value, _, tail, err := unquoteChar(body, '\'')
+
+	if err != nil {
+		return 0, err
+	}
+
+	if tail != "" {
+		return 0, errors.New("rune literal must contain exactly one character")
+	}
+
+	return value, nil
+}
+
+// Decodes the character or escape sequence at the start of body, which is
+// part of a literal delimited by quote.  isByte is set for `\xNN`, whose value
+// is a single byte rather than a code point.  Other bytes which aren't valid
+// UTF-8 are also returned as-is.
+func unquoteChar(body string, quote byte) (value rune, isByte bool, tail string, err error) {
+	if body[0] != '\\' {
+		value, size := utf8.DecodeRuneInString(body)
+
+		if value == utf8.RuneError && size == 1 {
+			return rune(body[0]), true, body[1:], nil
+		}
+
+		return value, false, body[size:], nil
+	}
+
+	if len(body) < 2 {
+		return 0, false, "", errors.New("unterminated escape sequence")
+	}
+
+	switch char := body[1]; char {
+	case 'n':
+		return '\n', false, body[2:], nil
+	case 't':
+		return '\t', false, body[2:], nil
+	case 'r':
+		return '\r', false, body[2:], nil
+	case '0':
+		return 0, false, body[2:], nil
+	case '\\', quote:
+		return rune(char), false, body[2:], nil
+	case 'x':
+		if len(body) < 4 || !isHexadecimal(rune(body[2])) || !isHexadecimal(rune(body[3])) {
+			return 0, false, "", errors.New("invalid escape sequence: expected two hexadecimal digits after '\\x'")
+		}
+
+		return rune(hexValue(body[2])<<4 | hexValue(body[3])), true, body[4:], nil
+	case 'u':
+		end := strings.IndexByte(body, '}')
+
+		if len(body) < 3 || body[2] != '{' || end < 4 || end > 9 {
+			return 0, false, "", errors.New("invalid escape sequence: expected one to six hexadecimal digits in '\\u{}'")
+		}
+
+		for _, digit := range []byte(body[3:end]) {
+			if !isHexadecimal(rune(digit)) {
+				return 0, false, "", errors.New("invalid escape sequence: expected one to six hexadecimal digits in '\\u{}'")
+			}
+
+			value = value<<4 | rune(hexValue(digit))
+		}
+
+		if !utf8.ValidRune(value) {
+			return 0, false, "", errors.New("invalid escape sequence: not a valid unicode code point")
+		}
+
+		return value, false, body[end+1:], nil
+	default:
+		return 0, false, "", errors.New("unknown escape sequence '\\" + string(char) + "'")
+	}
+}
@@ -0,0 +1,55 @@
+package lexer
+
+import "testing"
+
+func TestUnquote(t *testing.T) {
+	for _, test := range []struct {
+		raw, want string
+	}{
+		{`"abc"`, "abc"},
+		{`"a\tb\n"`, "a\tb\n"},
+		{`"\xff\x41"`, "\xffA"},
+		{`"\u{e9}\u{1F600}"`, "é😀"},
+		{`"\"\\"`, `"\`},
+		{"`a\\n\r\nb`", "a\\n\nb"},
+	} {
+		got, err := Unquote(test.raw)
+
+		if err != nil {
+			t.Errorf("Unquote(%s): %s", test.raw, err)
+		} else if got != test.want {
+			t.Errorf("Unquote(%s) = %q; want %q", test.raw, got, test.want)
+		}
+	}
+}
+
+func TestUnquoteChar(t *testing.T) {
+	for _, test := range []struct {
+		raw  string
+		want rune
+	}{
+		{`'a'`, 'a'},
+		{`'é'`, 'é'},
+		{`'\n'`, '\n'},
+		{`'\0'`, 0},
+		{`'\''`, '\''},
+		{`'\xff'`, 0xff},
+		{`'\x41'`, 'A'},
+		{`'\u{e9}'`, 'é'},
+		{`'\u{10FFFF}'`, 0x10ffff},
+	} {
+		got, err := UnquoteChar(test.raw)
+
+		if err != nil {
+			t.Errorf("UnquoteChar(%s): %s", test.raw, err)
+		} else if got != test.want {
+			t.Errorf("UnquoteChar(%s) = %d; want %d", test.raw, got, test.want)
+		}
+	}
+
+	for _, raw := range []string{`''`, `'ab'`, `'\x4'`, `'\u{110000}'`, `'\q'`, `"a"`} {
+		if got, err := UnquoteChar(raw); err == nil {
+			t.Errorf("UnquoteChar(%s) = %d; want an error", raw, got)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Code generated by genoperators from operators.yaml; DO NOT EDIT.
+
+package lexer
+
+// Operators and delimiters
+const (
+	operators_begin Token = values_end + 1 + iota
+
+	ADD         // +
+	SUB         // -
+	MUL         // *
+	DIV         // /
+	MOD         // %
+	AND         // &
+	OR          // |
+	XOR         // ^
+	LEFT_SHIFT  // <<
+	RIGHT_SHIFT // >>
+	AND_NOT     // &^
+	BOOLEAN_AND // &&
+	BOOLEAN_OR  // ||
+	INCR        // ++
+	DECR        // --
+	EQL         // ==
+	LESS        // <
+	GREATER     // >
+	ASSIGN      // =
+	NOT         // !
+	NOT_EQL     // !=
+	LESS_EQL    // <=
+	GREATER_EQL // >=
+	DEFINE      // :=
+	TILDE       // ~
+	ELLIPSIS    // ...
+	OPAREN      // (
+	OBRACK      // [
+	OBRACE      // {
+	COMMA       // ,
+	PERIOD      // .
+	CPAREN      // )
+	CBRACK      // ]
+	CBRACE      // }
+	SEMICOLON   // ;
+	COLON       // :
+
+	assignment_operators_begin
+	ADD_ASSIGN         // +=
+	SUB_ASSIGN         // -=
+	MUL_ASSIGN         // *=
+	DIV_ASSIGN         // /=
+	MOD_ASSIGN         // %=
+	AND_ASSIGN         // &=
+	OR_ASSIGN          // |=
+	XOR_ASSIGN         // ^=
+	LEFT_SHIFT_ASSIGN  // <<=
+	RIGHT_SHIFT_ASSIGN // >>=
+	AND_NOT_ASSIGN     // &^=
+	assignment_operators_end
+
+	operators_end
+)
+
+var (
+	operators = [...]string{
+		ADD:                "+",
+		ADD_ASSIGN:         "+=",
+		SUB:                "-",
+		SUB_ASSIGN:         "-=",
+		MUL:                "*",
+		MUL_ASSIGN:         "*=",
+		DIV:                "/",
+		DIV_ASSIGN:         "/=",
+		MOD:                "%",
+		MOD_ASSIGN:         "%=",
+		AND:                "&",
+		AND_ASSIGN:         "&=",
+		OR:                 "|",
+		OR_ASSIGN:          "|=",
+		XOR:                "^",
+		XOR_ASSIGN:         "^=",
+		LEFT_SHIFT:         "<<",
+		LEFT_SHIFT_ASSIGN:  "<<=",
+		RIGHT_SHIFT:        ">>",
+		RIGHT_SHIFT_ASSIGN: ">>=",
+		AND_NOT:            "&^",
+		AND_NOT_ASSIGN:     "&^=",
+		BOOLEAN_AND:        "&&",
+		BOOLEAN_OR:         "||",
+		INCR:               "++",
+		DECR:               "--",
+		EQL:                "==",
+		LESS:               "<",
+		GREATER:            ">",
+		ASSIGN:             "=",
+		NOT:                "!",
+		NOT_EQL:            "!=",
+		LESS_EQL:           "<=",
+		GREATER_EQL:        ">=",
+		DEFINE:             ":=",
+		TILDE:              "~",
+		ELLIPSIS:           "...",
+		OPAREN:             "(",
+		OBRACK:             "[",
+		OBRACE:             "{",
+		COMMA:              ",",
+		PERIOD:             ".",
+		CPAREN:             ")",
+		CBRACK:             "]",
+		CBRACE:             "}",
+		SEMICOLON:          ";",
+		COLON:              ":",
+	}
+
+	operatorLookupTable = [...]Token{
+		'+':                ADD,
+		'-':                SUB,
+		'*':                MUL,
+		'/':                DIV,
+		'%':                MOD,
+		'&':                AND,
+		'|':                OR,
+		'^':                XOR,
+		('<' | ('<' << 7)): LEFT_SHIFT,
+		('>' | ('>' << 7)): RIGHT_SHIFT,
+		('&' | ('^' << 7)): AND_NOT,
+		('&' | ('&' << 7)): BOOLEAN_AND,
+		('|' | ('|' << 7)): BOOLEAN_OR,
+		('+' | ('+' << 7)): INCR,
+		('-' | ('-' << 7)): DECR,
+		('=' | ('=' << 7)): EQL,
+		'<':                LESS,
+		'>':                GREATER,
+		'=':                ASSIGN,
+		'!':                NOT,
+		('!' | ('=' << 7)): NOT_EQL,
+		('<' | ('=' << 7)): LESS_EQL,
+		('>' | ('=' << 7)): GREATER_EQL,
+		(':' | ('=' << 7)): DEFINE,
+		'~':                TILDE,
+		'(':                OPAREN,
+		'[':                OBRACK,
+		'{':                OBRACE,
+		',':                COMMA,
+		'.':                PERIOD,
+		')':                CPAREN,
+		']':                CBRACK,
+		'}':                CBRACE,
+		';':                SEMICOLON,
+		':':                COLON,
+	}
+
+	assignmentOperators = [...]Token{
+		ADD:         ADD_ASSIGN,
+		SUB:         SUB_ASSIGN,
+		MUL:         MUL_ASSIGN,
+		DIV:         DIV_ASSIGN,
+		MOD:         MOD_ASSIGN,
+		AND:         AND_ASSIGN,
+		OR:          OR_ASSIGN,
+		XOR:         XOR_ASSIGN,
+		LEFT_SHIFT:  LEFT_SHIFT_ASSIGN,
+		RIGHT_SHIFT: RIGHT_SHIFT_ASSIGN,
+		AND_NOT:     AND_NOT_ASSIGN,
+	}
+
+	nonAssignmentOperators = [...]Token{
+		ADD_ASSIGN:         ADD,
+		SUB_ASSIGN:         SUB,
+		MUL_ASSIGN:         MUL,
+		DIV_ASSIGN:         DIV,
+		MOD_ASSIGN:         MOD,
+		AND_ASSIGN:         AND,
+		OR_ASSIGN:          OR,
+		XOR_ASSIGN:         XOR,
+		LEFT_SHIFT_ASSIGN:  LEFT_SHIFT,
+		RIGHT_SHIFT_ASSIGN: RIGHT_SHIFT,
+		AND_NOT_ASSIGN:     AND_NOT,
+	}
+
+	unaryOperators = [...]bool{
+		ADD:   true,
+		SUB:   true,
+		MUL:   true,
+		AND:   true,
+		INCR:  true,
+		DECR:  true,
+		NOT:   true,
+		TILDE: true,
+	}
+
+	precedences = [...]int{
+		ADD:         4,
+		SUB:         4,
+		MUL:         5,
+		DIV:         5,
+		MOD:         5,
+		AND:         5,
+		OR:          4,
+		XOR:         4,
+		LEFT_SHIFT:  5,
+		RIGHT_SHIFT: 5,
+		AND_NOT:     5,
+		BOOLEAN_AND: 2,
+		BOOLEAN_OR:  1,
+		INCR:        6,
+		DECR:        6,
+		EQL:         3,
+		LESS:        3,
+		GREATER:     3,
+		NOT_EQL:     3,
+		LESS_EQL:    3,
+		GREATER_EQL: 3,
+		OPAREN:      7,
+	}
+)
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"go/token"
+	"unicode"
+	"unicode/utf8"
 )
 
 // type Position token.Position
@@ -13,29 +15,41 @@ type PositionError struct {
 	error
 }
 
+func (p PositionError) Error() string {
+	return fmt.Sprintf("%s: %s", p.Position, p.error.Error())
+}
+
 // func (p Position) WrapError(err error) PosError {
 // 	return PosError{error: err, Position: p}
 // }
 
 // REFERENCE: https://github.com/golang/go/blob/7c5d7a4caffdb72ce252fb465ff4f7fd62a46c8a/src/go/scanner/scanner.go
 
-// const (
-// 	bom = 0xFEFF
-// 	eof = -1
-// )
+const bom = 0xFEFF // byte order mark, only allowed as the first character
+
+// A set of flags which control how the scanner behaves.
+type Mode uint
+
+const (
+	// Emit comments as COMMENT tokens instead of skipping them.
+	ScanComments Mode = 1 << iota
+)
 
 type Scanner struct {
 	src    []byte
 	offset int
-	char   byte
-
-	eof            bool
-	file           *token.File
-	didReadNewline bool
-}
-
-func (s *Scanner) DidReadNewline() bool {
-	return s.didReadNewline
+	// The current character, and its length in bytes.
+	char  rune
+	width int
+	mode  Mode
+
+	eof  bool
+	file *token.File
+	// Whether a newline should be read as a semicolon.
+	insertSemi bool
+
+	// Errors encountered while scanning.
+	Errors []PositionError
 }
 
 var t token.File
@@ -44,9 +58,14 @@ func (s *Scanner) PositionInfo(p token.Pos) token.Position {
 	return s.file.Position(p)
 }
 
-func NewScanner(src []byte, file *token.File) (scanner *Scanner, err error) {
-	scanner = &Scanner{file: file, char: src[0]}
+func NewScanner(src []byte, file *token.File, mode Mode) (scanner *Scanner, err error) {
+	scanner = &Scanner{file: file, mode: mode}
 	scanner.src = src
+	scanner.read()
+
+	if scanner.char == bom {
+		scanner.next()
+	}
 
 	return
 }
@@ -65,168 +84,536 @@ func (s *Scanner) errorf(offset int, msg string, args ...interface{}) PositionEr
 	}
 }
 
-func (s *Scanner) next() {
+// Creates an error positioned at p.
+func (s *Scanner) Errorf(p token.Pos, msg string, args ...interface{}) PositionError {
+	return PositionError{
+		error:    fmt.Errorf(msg, args...),
+		Position: s.file.Position(p),
+	}
+}
 
-	if s.eof {
+// decodes the character at s.offset.
+func (s *Scanner) read() {
+	if s.offset >= len(s.src) {
+		s.eof = true
+		s.char = 0
+		s.width = 0
 		return
 	}
 
-	s.offset++
+	s.char, s.width = rune(s.src[s.offset]), 1
 
-	if s.char == '\n' {
-		s.file.AddLine(s.offset)
-		s.didReadNewline = true
-	}
+	if s.char >= utf8.RuneSelf {
+		s.char, s.width = utf8.DecodeRune(s.src[s.offset:])
 
-	if s.offset >= len(s.src) {
+		if s.char == utf8.RuneError && s.width == 1 {
+			s.Errors = append(s.Errors, s.error(s.offset, "invalid UTF-8 encoding"))
+		} else if s.char == bom && s.offset > 0 {
+			s.Errors = append(s.Errors, s.error(s.offset, "invalid BOM in the middle of the file"))
+		}
+	}
+}
 
-		s.eof = true
-		s.char = 0
+func (s *Scanner) next() {
+	if s.eof {
 		return
 	}
 
-	s.char = s.src[s.offset]
+	if s.char == '\n' {
+		s.file.AddLine(s.offset + 1)
+	}
 
+	s.offset += s.width
+	s.read()
 }
 
-func isDecimal(char byte) bool {
+func isDecimal(char rune) bool {
 	return char >= '0' && char <= '9'
 }
 
+// Whether char is a decimal digit, including non-ascii digits (which are only
+// allowed in identifiers).
+func isDigit(char rune) bool {
+	return isDecimal(char) || char >= utf8.RuneSelf && unicode.IsDigit(char)
+}
+
 func (s *Scanner) peek() byte {
-	if s.offset+1 >= len(s.src) {
+	if s.offset+s.width >= len(s.src) {
 		return 0
 	}
 
-	return s.src[s.offset+1]
+	return s.src[s.offset+s.width]
 }
 
 func (s *Scanner) readIdentifier() string {
 	start := s.offset
 
-	for isLetter(s.char) || isDecimal(s.char) || s.char == '_' {
+	for isLetter(s.char) || isDigit(s.char) || s.char == '_' {
 		s.next()
 	}
 
 	return string(s.src[start:s.offset])
 }
 
-// reads a string in the form of "{string}"
-// (s.char should equal '"' prior to reading this)
-func (s *Scanner) readInlineString() (str string) {
+// reads a string or rune literal delimited by quote, ie "{string}" or '{rune}'.
+// Escape sequences are validated but not decoded (see Unquote).
+// (s.char should equal quote prior to reading this)
+func (s *Scanner) readQuoted(quote byte) (str string, ok bool) {
 	start := s.offset
+	ok = true
+	s.next()
 
 	for {
+		switch {
+		case s.eof, s.char == '\n':
+			s.Errors = append(s.Errors, s.error(start, "literal not terminated"))
+			return string(s.src[start:s.offset]), false
+		case s.char == rune(quote):
+			s.next()
+			return string(s.src[start:s.offset]), ok
+		case s.char == '\\':
+			ok = s.readEscape(quote) && ok
+		default:
+			s.next()
+		}
+	}
+}
+
+// reads a string in the form of `{string}`.  These can span multiple lines and
+// don't contain escape sequences.
+// (s.char should equal '`' prior to reading this)
+func (s *Scanner) readRawString() (str string, ok bool) {
+	start := s.offset
+	s.next()
+
+	for s.char != '`' {
+		if s.eof {
+			s.Errors = append(s.Errors, s.error(start, "raw string literal not terminated"))
+			return string(s.src[start:s.offset]), false
+		}
+
+		s.next()
+	}
+
+	s.next()
+	return string(s.src[start:s.offset]), true
+}
+
+// reads a rune literal in the form of '{rune}'.
+// (s.char should be a single quote prior to reading this)
+func (s *Scanner) readChar() (str string, ok bool) {
+	start := s.offset
+
+	if str, ok = s.readQuoted('\''); !ok {
+		return
+	}
+
+	// Escape sequences have already been checked, so the only error left is the
+	// number of characters.
+	if _, err := UnquoteChar(str); err != nil {
+		s.Errors = append(s.Errors, s.error(start, "rune literal must contain exactly one character"))
+		return str, false
+	}
+
+	return
+}
+
+// reads an escape sequence, ie `\n` or `\u{1F600}`, returning false if it's
+// invalid.  See Unquote for the supported sequences.
+// (s.char should equal '\\' prior to reading this)
+func (s *Scanner) readEscape(quote byte) bool {
+	start := s.offset
+	s.next()
+
+	switch s.char {
+	case 'n', 't', 'r', '0', '\\', rune(quote):
+		s.next()
+		return true
+	case 'x':
+		s.next()
+
+		for i := 0; i < 2; i++ {
+			if !isHexadecimal(s.char) {
+				s.Errors = append(s.Errors, s.error(start, "invalid escape sequence: expected two hexadecimal digits after '\\x'"))
+				return false
+			}
+
+			s.next()
+		}
+
+		return true
+	case 'u':
 		s.next()
 
-		if s.char == '"' {
+		if s.char != '{' {
+			s.Errors = append(s.Errors, s.error(start, "invalid escape sequence: expected '{' after '\\u'"))
+			return false
+		}
+
+		s.next()
+
+		var (
+			value  rune
+			digits int
+		)
+
+		for ; isHexadecimal(s.char); digits++ {
+			value = value<<4 | rune(hexValue(byte(s.char)))
 			s.next()
-			return string(s.src[start:s.offset])
 		}
 
-		if s.char == '\\' {
+		if digits == 0 || digits > 6 || s.char != '}' {
+			s.Errors = append(s.Errors, s.error(start, "invalid escape sequence: expected one to six hexadecimal digits terminated by '}' after '\\u{'"))
+			return false
+		}
+
+		s.next()
+
+		if value > unicode.MaxRune || 0xD800 <= value && value < 0xE000 {
+			s.Errors = append(s.Errors, s.errorf(start, "invalid escape sequence: %X is not a valid unicode code point", value))
+			return false
+		}
+
+		return true
+	case '\n':
+		// reported as an unterminated literal.
+		return false
+	}
+
+	if s.eof {
+		return false
+	}
+
+	s.Errors = append(s.Errors, s.errorf(start, "unknown escape sequence '\\%c'", s.char))
+	s.next()
+
+	return false
+}
+
+// reads a comment in the form of `// {comment}` or `/* {comment} */`.
+// Unlike go, block comments can be nested, ie `/* /* */ */` is a single comment.
+// (s.char should equal '/' prior to reading this)
+func (s *Scanner) readComment() string {
+	start := s.offset
+	s.next()
+
+	// `//`-style comments run until the end of the line.  The newline itself
+	// is left for the next token.
+	if s.char == '/' {
+		for s.char != '\n' && !s.eof {
 			s.next()
 		}
 
-		// todo: avoid panic and actually error properly...
-		if s.char == '\n' {
-			panic("not a string")
+		return string(s.src[start:s.offset])
+	}
+
+	s.next()
+
+	for depth := 1; depth > 0; {
+		if s.eof {
+			s.Errors = append(s.Errors, s.error(start, "comment not terminated"))
+			break
+		}
+
+		switch {
+		case s.char == '/' && s.peek() == '*':
+			s.next()
+			depth++
+		case s.char == '*' && s.peek() == '/':
+			s.next()
+			depth--
 		}
+
+		s.next()
 	}
+
+	return string(s.src[start:s.offset])
 }
 
 func isBinary(char rune) bool {
 	return char == '0' || char == '1'
 }
 
-func isHexadecimal(char byte) bool {
+func isHexadecimal(char rune) bool {
 	return (char >= '0' && char <= '9') ||
 		(char >= 'a' && char <= 'f') ||
 		(char <= 'F' && char >= 'A')
 }
 
-func isOctal(char byte) bool {
+func hexValue(char byte) byte {
+	switch {
+	case char >= 'a':
+		return char - 'a' + 10
+	case char >= 'A':
+		return char - 'A' + 10
+	}
+
+	return char - '0'
+}
+
+func isOctal(char rune) bool {
 	return '0' <= char && char <= '7'
 }
 
-func (s *Scanner) readFor(check func(byte) bool) {
+func (s *Scanner) readFor(check func(rune) bool) {
 	for check(s.char) {
 		s.next()
 	}
 }
 
+// reads digits of the given base (and '_' separators).  The offset of the first
+// digit that's invalid for the base is stored in invalid.
+// The returned bitset has bit 0 set if a digit was read, and bit 1 if a '_' was.
+func (s *Scanner) readDigits(base int, invalid *int) (digsep int) {
+	if base <= 10 {
+		max := rune('0' + base)
+
+		for isDecimal(s.char) || s.char == '_' {
+			ds := 1
+			if s.char == '_' {
+				ds = 2
+			} else if s.char >= max && *invalid < 0 {
+				*invalid = s.offset
+			}
+
+			digsep |= ds
+			s.next()
+		}
+
+		return
+	}
+
+	for isHexadecimal(s.char) || s.char == '_' {
+		ds := 1
+		if s.char == '_' {
+			ds = 2
+		}
+
+		digsep |= ds
+		s.next()
+	}
+
+	return
+}
+
+// Reads an integer or float literal, following go's syntax (plus `0d` for explicitly decimal integers):
+//
+//	42  4_2  0600  0o600  0x2A  0b101010  0d42
+//	.5  1.  1e-3  0x1.8p3  1_000.000_1
+//
+// Partially sourced from https://github.com/golang/go/blob/3d7cb23e3d5e7880d582f1b0300064bd1138f3ee/src/go/scanner/scanner.go#L348
 func (s *Scanner) readNumber() (kind Token) {
 	var (
-		isExponential bool   // whether the number contains `e` or not.
-		canHaveDot    = true // whether the number can have a dot or not.
+		start   = s.offset
+		base    = 10
+		prefix  = rune(0) // one of 0 (decimal), '0' (0-octal), 'x', 'o', 'b' or 'd'
+		digsep  = 0       // bit 0: digit present, bit 1: '_' present
+		invalid = -1      // offset of an invalid digit in literal, or < 0
 	)
 
-	kind = INT
+	// integer part
+	if s.char != '.' {
+		kind = INT
+
+		if s.char == '0' {
+			s.next()
+
+			switch lower(s.char) {
+			case 'x':
+				s.next()
+				base, prefix = 16, 'x'
+			case 'o':
+				s.next()
+				base, prefix = 8, 'o'
+			case 'b':
+				s.next()
+				base, prefix = 2, 'b'
+			case 'd':
+				s.next()
+				base, prefix = 10, 'd'
+			default:
+				base, prefix = 8, '0'
+				digsep = 1 // leading 0
+			}
+		}
+
+		digsep |= s.readDigits(base, &invalid)
+	}
 
+	// fractional part
 	if s.char == '.' {
+		kind = FLOAT
+
+		if prefix == 'o' || prefix == 'b' || prefix == 'd' {
+			s.Errors = append(s.Errors, s.error(s.offset, "invalid radix point in "+literalName(prefix)))
+		}
+
 		s.next()
-		s.readFor(isDecimal)
-		return INT
+		digsep |= s.readDigits(base, &invalid)
 	}
 
-	if s.char == '0' {
+	if digsep&1 == 0 {
+		s.Errors = append(s.Errors, s.error(s.offset, literalName(prefix)+" has no digits"))
+	}
+
+	// exponent
+	if e := lower(s.char); e == 'e' || e == 'p' {
+		switch {
+		case e == 'e' && prefix != 0 && prefix != '0':
+			s.Errors = append(s.Errors, s.errorf(s.offset, "'%c' exponent requires decimal mantissa", s.char))
+		case e == 'p' && prefix != 'x':
+			s.Errors = append(s.Errors, s.errorf(s.offset, "'%c' exponent requires hexadecimal mantissa", s.char))
+		}
+
 		s.next()
+		kind = FLOAT
 
-		switch s.char {
-		case 'x':
-			s.next()
-			s.readFor(isHexadecimal)
-			return INT
-		case 'o':
-			s.next()
-			s.readFor(isOctal)
-			return INT
-		case 'd':
+		if s.char == '+' || s.char == '-' {
 			s.next()
-			s.readFor(isDecimal)
-			return INT
 		}
 
-		if !isDecimal(s.char) {
-			return
+		ds := s.readDigits(10, &invalid)
+		digsep |= ds
+
+		if ds&1 == 0 {
+			s.Errors = append(s.Errors, s.error(s.offset, "exponent has no digits"))
 		}
+	} else if prefix == 'x' && kind == FLOAT {
+		s.Errors = append(s.Errors, s.error(s.offset, "hexadecimal mantissa requires a 'p' exponent"))
 	}
 
-	for {
-		s.next()
+	if kind == INT && invalid >= 0 {
+		s.Errors = append(s.Errors, s.errorf(invalid, "invalid digit '%c' in %s", s.src[invalid], literalName(prefix)))
+	}
+
+	if digsep&2 != 0 {
+		if i := invalidSeparator(string(s.src[start:s.offset])); i >= 0 {
+			s.Errors = append(s.Errors, s.error(start+i, "'_' must separate successive digits"))
+		}
+	}
+
+	return
+}
+
+func literalName(prefix rune) string {
+	switch prefix {
+	case 'x':
+		return "hexadecimal literal"
+	case 'o', '0':
+		return "octal literal"
+	case 'b':
+		return "binary literal"
+	}
+
+	return "decimal literal"
+}
+
+// Returns the index of the first invalid '_' in a number literal, or -1.
+func invalidSeparator(lit string) int {
+	var (
+		x1 = ' ' // prefix char, we only care if it's 'x'
+		d  = '.' // digit, one of '_', '0' (a digit), or '.' (anything else)
+		i  = 0
+	)
+
+	// a prefix counts as a digit
+	if len(lit) >= 2 && lit[0] == '0' {
+		x1 = lower(rune(lit[1]))
+		if x1 == 'x' || x1 == 'o' || x1 == 'b' || x1 == 'd' {
+			d = '0'
+			i = 2
+		}
+	}
+
+	for ; i < len(lit); i++ {
+		p := d // previous digit
+		d = rune(lit[i])
 
 		switch {
-		case isDecimal(s.char), s.char == '_':
-		case s.char == '.':
-			if !canHaveDot {
-				return
+		case d == '_':
+			if p != '0' {
+				return i
 			}
-
-			kind = FLOAT
-			canHaveDot = false
-		case s.char == 'e':
-			if isExponential {
-				return
+		case isDecimal(d) || x1 == 'x' && isHexadecimal(d):
+			d = '0'
+		default:
+			if p == '_' {
+				return i - 1
 			}
 
-			isExponential = true
-			canHaveDot = false
-		default:
-			return
+			d = '.'
 		}
+	}
 
+	if d == '_' {
+		return len(lit) - 1
 	}
+
+	return -1
+}
+
+// lowercases an ascii letter.
+func lower(char rune) rune {
+	return ('a' - 'A') | char
 }
 
 // todo: operator shit, reference: https://github.com/golang/go/blob/1d004fa2015d128acf6302fc74b95f6a36c35680/src/go/scanner/scax#L762
 
 func (s *Scanner) skipWhitespace() {
-	for s.char == ' ' || s.char == '\t' || s.char == '\r' || s.char == '\n' {
+	for s.char == ' ' || s.char == '\t' || s.char == '\r' || s.char == '\n' && !s.insertSemi {
 		s.next()
 	}
 }
 
-func isLetter(char byte) bool {
+// Whether the comment(s) at the current offset run until the end of the line,
+// eg `/* a */ // b`.  Doesn't advance the scanner.
+func (s *Scanner) commentEndsLine() bool {
+	for i := s.offset; ; {
+		for i < len(s.src) && (s.src[i] == ' ' || s.src[i] == '\t' || s.src[i] == '\r') {
+			i++
+		}
+
+		if i >= len(s.src) || s.src[i] == '\n' {
+			return true
+		}
+
+		if s.src[i] != '/' || i+1 >= len(s.src) {
+			return false
+		}
+
+		switch s.src[i+1] {
+		case '/':
+			return true
+		case '*':
+			i += 2
+
+			for depth := 1; depth > 0; i++ {
+				if i >= len(s.src) || s.src[i] == '\n' {
+					return true
+				}
+
+				if i+1 < len(s.src) {
+					switch {
+					case s.src[i] == '/' && s.src[i+1] == '*':
+						depth++
+						i++
+					case s.src[i] == '*' && s.src[i+1] == '/':
+						depth--
+						i++
+					}
+				}
+			}
+		default:
+			return false
+		}
+	}
+}
+
+func isLetter(char rune) bool {
+	if char >= utf8.RuneSelf {
+		return unicode.IsLetter(char)
+	}
+
 	// The lowercase variants of letters are exactly 32 higher than the same variant in
 	/// uppercase.
 	// &^ will convert those to lowercase.
@@ -237,18 +624,50 @@ func isLetter(char byte) bool {
 
 // Partially sourced from https://github.com/golang/go/blob/7c5d7a4caffdb72ce252fb465ff4f7fd62a46c8a/src/go/scanner/scanner.go#L829
 
+// Reads the next token.
+//
+// Like go, semicolons are inserted automatically: a newline (or the end of the
+// file) is read as a SEMICOLON if the line's final token is an identifier, a
+// literal, `nil`, `return`, `)`, `]`, `}`, `++` or `--`.  A comment which runs
+// until the end of the line counts as a newline.  Automatic semicolons have the
+// raw value "\n".
 func (s *Scanner) Next() (tok Token, raw string, pos token.Pos) {
-	s.didReadNewline = false
+	tok, raw, pos = s.scan()
+
+	switch {
+	case tok == COMMENT:
+	case tok == INVALID && raw != "":
+		// malformed literals end lines just like valid ones.
+		s.insertSemi = true
+	default:
+		s.insertSemi = tok.insertsSemicolon()
+	}
+
+	return
+}
+
+func (s *Scanner) scan() (tok Token, raw string, pos token.Pos) {
+scanAgain:
 	s.skipWhitespace()
 
 	start := s.offset
+	pos = s.file.Pos(start)
+
 	if s.eof {
+		if s.insertSemi {
+			return SEMICOLON, "\n", pos
+		}
+
 		tok = EOF
 		return
 	}
-	pos = s.file.Pos(start)
 
 	switch char := s.char; {
+	case char == '\n':
+		// skipWhitespace only stops at newlines when a semicolon is needed.
+		s.next()
+		tok = SEMICOLON
+		raw = "\n"
 	case isLetter(char), char == '_':
 		ident := s.readIdentifier()
 
@@ -262,13 +681,46 @@ func (s *Scanner) Next() (tok Token, raw string, pos token.Pos) {
 			raw = ident
 		}
 
-	case isDecimal(char), char == '.' && isDecimal(s.peek()):
-		tok = s.readNumber()
+	case isDecimal(char), char == '.' && isDecimal(rune(s.peek())):
+		errCount := len(s.Errors)
+
+		if tok = s.readNumber(); len(s.Errors) > errCount {
+			tok = INVALID
+		}
+
 		raw = string(s.src[start:s.offset])
 	case char == '"':
-		tok = STRING
-		raw = s.readInlineString()
-		pos = token.Pos(start)
+		var ok bool
+
+		if raw, ok = s.readQuoted('"'); ok {
+			tok = STRING
+		}
+	case char == '`':
+		var ok bool
+
+		if raw, ok = s.readRawString(); ok {
+			tok = STRING
+		}
+	case char == '\'':
+		var ok bool
+
+		if raw, ok = s.readChar(); ok {
+			tok = CHAR
+		}
+	case char == '/' && (s.peek() == '/' || s.peek() == '*'):
+		if s.insertSemi && s.commentEndsLine() {
+			// the comment is read on the next call.
+			return SEMICOLON, "\n", pos
+		}
+
+		comment := s.readComment()
+
+		if s.mode&ScanComments == 0 {
+			goto scanAgain
+		}
+
+		tok = COMMENT
+		raw = comment
 	default:
 		if s.char == '.' && s.peek() == '.' {
 			s.next()
@@ -284,7 +736,6 @@ func (s *Scanner) Next() (tok Token, raw string, pos token.Pos) {
 			tok = PERIOD
 			return
 		}
-		didReadNewline := s.didReadNewline
 		if op, didReadNext := lookupOperator(s.char, s.peek()); op != INVALID {
 			if didReadNext {
 				s.next()
@@ -297,14 +748,15 @@ func (s *Scanner) Next() (tok Token, raw string, pos token.Pos) {
 			}
 
 			tok = op
-
-			if tok == SEMICOLON && didReadNewline {
-				// honestly what goes through someone's head when they do this shit
-				panic("terrible syntax decisions")
-			}
-
 			return
 		}
+
+		// invalid encodings and BOMs were reported when they were read.
+		if s.char != utf8.RuneError && s.char != bom {
+			s.Errors = append(s.Errors, s.errorf(start, "illegal character %#U", s.char))
+		}
+
+		s.next()
 	}
 
 	return
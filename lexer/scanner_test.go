@@ -0,0 +1,168 @@
+package lexer
+
+import (
+	"go/token"
+	"testing"
+)
+
+type scanned struct {
+	tok Token
+	raw string
+}
+
+func scanAll(t *testing.T, src string) []scanned {
+	t.Helper()
+
+	file := token.NewFileSet().AddFile("test.tbd", -1, len(src))
+	s, err := NewScanner([]byte(src), file, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var toks []scanned
+
+	for {
+		tok, raw, _ := s.Next()
+
+		if tok == EOF {
+			break
+		}
+
+		toks = append(toks, scanned{tok, raw})
+
+		if len(toks) > 100 {
+			t.Fatal("scanner didn't reach EOF")
+		}
+	}
+
+	for _, err := range s.Errors {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	return toks
+}
+
+var semi = scanned{SEMICOLON, "\n"}
+
+func ident(name string) scanned {
+	return scanned{IDENTIFIER, name}
+}
+
+func TestSemicolonInsertion(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		src  string
+		want []scanned
+	}{
+		{
+			name: "identifier at line end",
+			src:  "a\nb",
+			want: []scanned{ident("a"), semi, ident("b"), semi},
+		},
+		{
+			name: "literals at line end",
+			src:  "1\n1.5\n'a'\n\"a\"\n",
+			want: []scanned{{INT, "1"}, semi, {FLOAT, "1.5"}, semi, {CHAR, "'a'"}, semi, {STRING, `"a"`}, semi},
+		},
+		{
+			name: "trailing binary operator continues the line",
+			src:  "a +\nb",
+			want: []scanned{ident("a"), {ADD, ""}, ident("b"), semi},
+		},
+		{
+			name: "trailing logical operator continues the line",
+			src:  "a &&\nb ||\nc",
+			want: []scanned{ident("a"), {BOOLEAN_AND, ""}, ident("b"), {BOOLEAN_OR, ""}, ident("c"), semi},
+		},
+		{
+			name: "trailing comma and period continue the line",
+			src:  "f(a,\nb)\nc.\nd",
+			want: []scanned{ident("f"), {OPAREN, ""}, ident("a"), {COMMA, ""}, ident("b"), {CPAREN, ""}, semi, ident("c"), {PERIOD, ""}, ident("d"), semi},
+		},
+		{
+			name: "closing paren at line end",
+			src:  "f()\ng",
+			want: []scanned{ident("f"), {OPAREN, ""}, {CPAREN, ""}, semi, ident("g"), semi},
+		},
+		{
+			name: "closing bracket at line end",
+			src:  "a[1]\nb",
+			want: []scanned{ident("a"), {OBRACK, ""}, {INT, "1"}, {CBRACK, ""}, semi, ident("b"), semi},
+		},
+		{
+			name: "closing brace at line end",
+			src:  "{\n}\na",
+			want: []scanned{{OBRACE, ""}, {CBRACE, ""}, semi, ident("a"), semi},
+		},
+		{
+			name: "opening delimiters continue the line",
+			src:  "f(\na[\nb{\n",
+			want: []scanned{ident("f"), {OPAREN, ""}, ident("a"), {OBRACK, ""}, ident("b"), {OBRACE, ""}},
+		},
+		{
+			name: "keywords at line end",
+			src:  "return\nnil\nbreak\nfunc\n",
+			want: []scanned{{RETURN, ""}, semi, {NIL, ""}, semi, {BREAK, ""}, semi, {FUNC, ""}},
+		},
+		{
+			name: "increment and decrement at line end",
+			src:  "a++\nb--\n",
+			want: []scanned{ident("a"), {INCR, ""}, semi, ident("b"), {DECR, ""}, semi},
+		},
+		{
+			name: "line comment at line end",
+			src:  "a // b\nc",
+			want: []scanned{ident("a"), semi, ident("c"), semi},
+		},
+		{
+			name: "block comment at line end",
+			src:  "a /* b */\nc",
+			want: []scanned{ident("a"), semi, ident("c"), semi},
+		},
+		{
+			name: "block comment spanning lines",
+			src:  "a /* b\n */ c",
+			want: []scanned{ident("a"), semi, ident("c"), semi},
+		},
+		{
+			name: "block comment within the line",
+			src:  "a /* b */ + c",
+			want: []scanned{ident("a"), {ADD, ""}, ident("c"), semi},
+		},
+		{
+			name: "comment after a binary operator",
+			src:  "a + // b\nc",
+			want: []scanned{ident("a"), {ADD, ""}, ident("c"), semi},
+		},
+		{
+			name: "comment at the end of the file",
+			src:  "a // b",
+			want: []scanned{ident("a"), semi},
+		},
+		{
+			name: "explicit semicolon",
+			src:  "a; b",
+			want: []scanned{ident("a"), {SEMICOLON, ""}, ident("b"), semi},
+		},
+		{
+			name: "blank lines",
+			src:  "\n\na\n\n\nb\n\n",
+			want: []scanned{ident("a"), semi, ident("b"), semi},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := scanAll(t, test.src)
+
+			if len(got) != len(test.want) {
+				t.Fatalf("scanned %v; want %v", got, test.want)
+			}
+
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("token %d is %v; want %v (scanned %v)", i, got[i], test.want[i], got)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"unicode"
+	"unicode/utf8"
 )
 
 // Parts of this file are sourced or directly copied from google's golang parser.
@@ -10,7 +11,9 @@ import (
 // A lexical token
 type Token int
 
-// TODO: These descriptors would be much neater in a yaml file.
+//go:generate go run ./genoperators
+
+// Operators and delimiters are generated from operators.yaml into operators.go.
 
 const (
 	INVALID Token = iota
@@ -23,90 +26,30 @@ const (
 	IDENTIFIER // something
 	INT        // 543
 	FLOAT      // 6543.23
-	// CHAR   // 'a'  // TODO
-	STRING // "blah"
+	CHAR       // 'a'
+	STRING     // "blah"
 	literal_end
 
 	values_end
+)
 
-	// Operators and delimiters
-	operators_begin
-	ADD // +
-	SUB // -
-	MUL // *
-	DIV // /
-	MOD // %
-
-	AND         // &
-	OR          // |
-	XOR         // ^
-	LEFT_SHIFT  // <<
-	RIGHT_SHIFT // >>
-	AND_NOT     // &^
-
-	assignment_operators_begin
-
-	ADD_ASSIGN // +=
-	SUB_ASSIGN // -=
-	MUL_ASSIGN // *=
-	QUO_ASSIGN // /=
-	REM_ASSIGN // %=
-
-	AND_ASSIGN         // &=
-	OR_ASSIGN          // |=
-	XOR_ASSIGN         // ^=
-	LEFT_SHIFT_ASSIGN  // <<=
-	RIGHT_SHIFT_ASSIGN // >>=
-	AND_NOT_ASSIGN     // &^=
-
-	assignment_operators_end
-
-	BOOLEAN_AND // &&
-	BOOLEAN_OR  // ||
-	INCR        // ++
-	DECR        // --
-
-	EQL     // ==
-	LESS    // <
-	GREATER // >
-	ASSIGN  // =
-	NOT     // !
-
-	NOT_EQL     // !=
-	LESS_EQL    // <=
-	GREATER_EQL // >=
-	DEFINE      // :=
-	TILDE       // ~
-	ELLIPSIS    // ...
-
-	OPAREN // (
-	OBRACK // [
-	OBRACE // {
-	COMMA  // ,
-	PERIOD // .
-
-	CPAREN    // )
-	CBRACK    // ]
-	CBRACE    // }
-	SEMICOLON // ;
-	COLON     // :
-
-	operators_end
-
-	keyword_begin
+const (
+	keyword_begin Token = operators_end + 1 + iota
 	// Keywords
 	CONST
 	PUBLIC
 	NIL
-	// CASE
-	// BREAK
-	// CONTINUE
+	CASE
+	BREAK
+	CATCH
+	CONTINUE
 
-	// DEFAULT
+	DEFAULT
 	// DEFER
 	ELSE
-	// FALLTHROUGH
-	// FOR
+	ENUM
+	FALLTHROUGH
+	FOR
 
 	FUNC
 	// GO
@@ -121,8 +64,10 @@ const (
 	RETURN
 
 	// SELECT
+	START
 	STRUCT
-	// SWITCH
+	SWITCH
+	THROW
 	// TYPE
 	VAR
 	keyword_end
@@ -137,69 +82,23 @@ var (
 		IDENTIFIER: "IDENTIFIER",
 		INT:        "INT",
 		FLOAT:      "FLOAT",
+		CHAR:       "CHAR",
 		STRING:     "STRING",
 
-		ADD: "+",
-		SUB: "-",
-		MUL: "*",
-		DIV: "/",
-		MOD: "%",
-
-		AND:         "&",
-		OR:          "|",
-		XOR:         "^",
-		LEFT_SHIFT:  "<<",
-		RIGHT_SHIFT: ">>",
-		AND_NOT:     "&^",
-
-		ADD_ASSIGN: "+=",
-		SUB_ASSIGN: "-=",
-		MUL_ASSIGN: "*=",
-		QUO_ASSIGN: "/=",
-		REM_ASSIGN: "%=",
-
-		AND_ASSIGN:         "&=",
-		OR_ASSIGN:          "|=",
-		XOR_ASSIGN:         "^=",
-		LEFT_SHIFT_ASSIGN:  "<<=",
-		RIGHT_SHIFT_ASSIGN: ">>=",
-		AND_NOT_ASSIGN:     "&^=",
-
-		BOOLEAN_AND: "&&",
-		BOOLEAN_OR:  "||",
-		INCR:        "++",
-		DECR:        "--",
-
-		EQL:     "==",
-		LESS:    "<",
-		GREATER: ">",
-		ASSIGN:  "=",
-		NOT:     "!",
-
-		NOT_EQL:     "!=",
-		LESS_EQL:    "<=",
-		GREATER_EQL: ">=",
-		DEFINE:      ":=",
-		TILDE:       "~",
-		ELLIPSIS:    "...",
-
-		OPAREN: "(",
-		OBRACK: "[",
-		OBRACE: "{",
-		COMMA:  ",",
-		PERIOD: ".",
-
-		CPAREN:    ")",
-		CBRACK:    "]",
-		CBRACE:    "}",
-		SEMICOLON: ";",
-		COLON:     ":",
-
 		CONST:  "const",
 		PUBLIC: "public",
 		NIL:    "nil",
 
-		ELSE: "else",
+		CASE:     "case",
+		BREAK:    "break",
+		CATCH:    "catch",
+		CONTINUE: "continue",
+
+		DEFAULT:     "default",
+		ELSE:        "else",
+		ENUM:        "enum",
+		FALLTHROUGH: "fallthrough",
+		FOR:         "for",
 
 		FUNC:   "func",
 		IF:     "if",
@@ -208,49 +107,14 @@ var (
 		INTERFACE: "interface",
 		RETURN:    "return",
 
+		START:  "start",
 		STRUCT: "struct",
+		SWITCH: "switch",
+		THROW:  "throw",
 		VAR:    "var",
 	}
 
-	keywords            = make(map[string]Token)
-	operatorLookupTable = [...]Token{
-		'+':                ADD,
-		'-':                SUB,
-		'*':                MUL,
-		'/':                DIV,
-		'%':                MOD,
-		'&':                AND,
-		'|':                OR,
-		'^':                XOR,
-		('<' | ('<' << 7)): LEFT_SHIFT,
-		('>' | ('>' << 7)): RIGHT_SHIFT,
-		('&' | ('^' << 7)): AND_NOT,
-		('&' | ('&' << 7)): BOOLEAN_AND,
-		('|' | ('|' << 7)): BOOLEAN_OR,
-		('+' | ('+' << 7)): INCR,
-		('-' | ('-' << 7)): DECR,
-		(':' | ('=' << 7)): DEFINE,
-		('!' | ('=' << 7)): NOT_EQL,
-		('=' | ('=' << 7)): EQL,
-
-		('<' | ('=' << 7)): LESS_EQL,
-		('>' | ('=' << 7)): GREATER_EQL,
-		'<':                LESS,
-		'>':                GREATER,
-		'=':                ASSIGN,
-		'!':                NOT,
-		'(':                OPAREN,
-		'[':                OBRACK,
-		'{':                OBRACE,
-		',':                COMMA,
-		'.':                PERIOD,
-		')':                CPAREN,
-		']':                CBRACK,
-		'}':                CBRACE,
-		':':                COLON,
-		';':                SEMICOLON,
-		'~':                TILDE,
-	}
+	keywords = make(map[string]Token)
 )
 
 func init() {
@@ -261,15 +125,17 @@ func init() {
 	}
 }
 
-func lookupOperator(char byte, next byte) (op Token, didReadNext bool) {
-	// check if the char is ascii
-	if char&0x80 == 0 {
-		index := (uint16(next) << 7) | uint16(char)
-		if index < uint16(len(operatorLookupTable)) {
+func lookupOperator(char rune, next byte) (op Token, didReadNext bool) {
+	// non-ascii characters are never operators
+	if char >= utf8.RuneSelf {
+		return INVALID, false
+	}
+
+	index := (uint16(next) << 7) | uint16(char)
+	if index < uint16(len(operatorLookupTable)) {
 
-			if maybeToken := operatorLookupTable[index]; maybeToken != INVALID {
-				return maybeToken, true
-			}
+		if maybeToken := operatorLookupTable[index]; maybeToken != INVALID {
+			return maybeToken, true
 		}
 	}
 
@@ -294,27 +160,42 @@ func (t Token) IsAssignmentOperator() bool {
 
 // Gets t as a non-assignment operator.  IE *= -> *
 func (t Token) GetNonAssignmentOperator() Token {
-	return t - (assignment_operators_begin - operators_begin)
+	return nonAssignmentOperators[t]
+}
+
+// Whether t can be used as a prefix operator, ie `-a`
+func (t Token) IsUnaryOperator() bool {
+	return 0 <= t && int(t) < len(unaryOperators) && unaryOperators[t]
 }
 
 func (t Token) InspectCustom() interface{} {
-	if int(t) < len(tokens) && tokens[t] != "" {
-		return tokens[t]
+	if str := t.String(); str != "" {
+		return str
 	}
 
 	return int(t)
 }
 
 func (t Token) hasAssignmentOperator() bool {
-	return operators_begin < t && t < assignment_operators_begin
+	return 0 <= t && int(t) < len(assignmentOperators) && assignmentOperators[t] != INVALID
 }
 
 func (t Token) getAssignmentOperator() Token {
-	return t + (assignment_operators_begin - operators_begin)
+	return assignmentOperators[t]
 }
 
 func (t Token) IsKeyword() bool { return keyword_begin < t && t < keyword_end }
 
+// Whether a newline directly after t should be read as a semicolon.
+func (t Token) insertsSemicolon() bool {
+	switch t {
+	case IDENTIFIER, INT, FLOAT, CHAR, STRING, NIL, RETURN, BREAK, CONTINUE, FALLTHROUGH, CPAREN, CBRACK, CBRACE, INCR, DECR:
+		return true
+	}
+
+	return false
+}
+
 func isKeyword(str string) bool {
 	_, ok := keywords[str]
 	return ok
@@ -329,34 +210,25 @@ func isIdentifier(name string) bool {
 	return name != "" && !isKeyword(name)
 }
 
-// For now, this function is copied from https://github.com/golang/go/blob/f6647f2e3bc0b803a67c97a7d5d8733cefbd5d5b/src/go/token/token.go#L270
-// (with some minor changes for variable names.)
-// TODO: Optimize via a table containing the metadata of operators.
-
 // Precedence returns the operator precedence of the binary
 // operator op. If op is not a binary operator, the result
-// is LowestPrecedence.
+// is -1.
 func (t Token) Precedence() int {
-	switch t {
-	case BOOLEAN_OR:
-		return 1
-	case BOOLEAN_AND:
-		return 2
-	case EQL, NOT_EQL, LESS, LESS_EQL, GREATER, GREATER_EQL:
-		return 3
-	case ADD, SUB, OR, XOR:
-		return 4
-	case MUL, DIV, MOD, LEFT_SHIFT, RIGHT_SHIFT, AND, AND_NOT:
-		return 5
-	case INCR, DECR:
-		return 6
-	case OPAREN:
-		return 7
+	if 0 <= t && int(t) < len(precedences) && precedences[t] != 0 {
+		return precedences[t]
 	}
 
 	return -1
 }
 
 func (t Token) String() string {
-	return tokens[t]
+	if t.IsOperator() {
+		return operators[t]
+	}
+
+	if 0 <= t && int(t) < len(tokens) {
+		return tokens[t]
+	}
+
+	return ""
 }
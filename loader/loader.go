@@ -0,0 +1,197 @@
+// Package loader loads a program along with the modules it imports.
+//
+// A module is a directory of .tbd files which share the same scope.  Imports
+// are resolved against each directory of the search path in turn, ie
+// `import "factorio"` is the first `<dir>/factorio` directory.
+package loader
+
+import (
+	"fmt"
+	"go/token"
+	"main/generator"
+	"main/parser"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The file extension of source files.
+const Ext = ".tbd"
+
+// An error in one of a program's files.
+type Error struct {
+	token.Position
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Message)
+}
+
+type Loader struct {
+	// The directories imports are resolved against, in order.
+	SearchPath []string
+	Fset       *token.FileSet
+
+	modules map[string]*generator.Module
+	// Modules in the order they were processed (dependencies first).
+	order []*generator.Module
+	// The paths of the modules currently being loaded, used to detect cycles.
+	stack  []string
+	errors []error
+}
+
+func New(searchPath ...string) *Loader {
+	return &Loader{
+		SearchPath: searchPath,
+		Fset:       token.NewFileSet(),
+		modules:    map[string]*generator.Module{},
+	}
+}
+
+// Loads the program at path (either a single file or a module's directory) and
+// every module it imports.  The modules are returned with dependencies before
+// the modules which import them; the program itself is last.
+func (l *Loader) Load(path string) ([]*generator.Module, []error) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	files := []string{path}
+
+	if info.IsDir() {
+		if files, err = sourceFiles(path); err != nil {
+			return nil, []error{err}
+		}
+	}
+
+	l.load("main", files)
+
+	return l.order, l.errors
+}
+
+// The source files of the module in dir, sorted by name.
+func sourceFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == Ext {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	return files, nil
+}
+
+// The directory of the module imported by path.
+func (l *Loader) resolve(path string) (string, bool) {
+	for _, dir := range l.SearchPath {
+		if info, err := os.Stat(filepath.Join(dir, path)); err == nil && info.IsDir() {
+			return filepath.Join(dir, path), true
+		}
+	}
+
+	return "", false
+}
+
+func (l *Loader) errorAt(pos token.Pos, str string, values ...interface{}) {
+	l.errors = append(l.errors, Error{Position: l.Fset.Position(pos), Message: fmt.Sprintf(str, values...)})
+}
+
+// Parses the files of a module, then loads its imports before processing it.
+func (l *Loader) load(path string, files []string) *generator.Module {
+	var ast parser.ModuleNode
+
+	for _, name := range files {
+		content, err := os.ReadFile(name)
+
+		if err != nil {
+			l.errors = append(l.errors, err)
+			continue
+		}
+
+		file := l.Fset.AddFile(name, -1, len(content))
+		mod, errs := parser.NewParser(content, file).ParseModule()
+
+		for _, err := range errs {
+			l.errors = append(l.errors, err)
+		}
+
+		ast.Nodes = append(ast.Nodes, mod.Nodes...)
+	}
+
+	l.stack = append(l.stack, path)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	imports := map[string]*generator.Module{}
+
+	for _, node := range ast.Nodes {
+		imp, ok := node.(parser.ImportDeclarationNode)
+
+		if !ok {
+			continue
+		}
+
+		if mod := l.importModule(imp); mod != nil {
+			imports[imp.Path] = mod
+		}
+	}
+
+	mod := generator.ProcessModule(ast, imports)
+	mod.Path = path
+
+	for i := range mod.Errors {
+		err := &mod.Errors[i]
+		l.errorAt(err.Node().Start(), "%s", err.Error())
+	}
+
+	l.order = append(l.order, &mod)
+
+	return &mod
+}
+
+func (l *Loader) importModule(node parser.ImportDeclarationNode) *generator.Module {
+	for i, path := range l.stack {
+		if path == node.Path {
+			cycle := append(append([]string{}, l.stack[i:]...), node.Path)
+			l.errorAt(node.Start(), "import cycle not allowed: %s", strings.Join(cycle, " -> "))
+			return nil
+		}
+	}
+
+	if mod, ok := l.modules[node.Path]; ok {
+		return mod
+	}
+
+	dir, ok := l.resolve(node.Path)
+
+	if !ok {
+		l.errorAt(node.Start(), "unable to find module '%s' in any of: %s", node.Path, strings.Join(l.SearchPath, ", "))
+		return nil
+	}
+
+	files, err := sourceFiles(dir)
+
+	if err != nil {
+		l.errors = append(l.errors, err)
+		return nil
+	}
+
+	if len(files) == 0 {
+		l.errorAt(node.Start(), "module '%s' has no source files", node.Path)
+		return nil
+	}
+
+	mod := l.load(node.Path, files)
+	l.modules[node.Path] = mod
+
+	return mod
+}
@@ -0,0 +1,126 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		// The source of each file, by its path under the search path.  The
+		// program is main.tbd.
+		files map[string]string
+		// The paths of the modules loaded, in order.
+		order []string
+		err   string
+	}{
+		{
+			name: "dependencies first",
+			files: map[string]string{
+				"main.tbd": "import \"a\"\n\nfunc main() {\n\ta.F()\n}\n",
+				"a/a.tbd":  "import \"b\"\n\npublic func F() int {\n\treturn b.G()\n}\n",
+				"b/b.tbd":  "public func G() int {\n\treturn 1\n}\n",
+			},
+			order: []string{"b", "a", "main"},
+		},
+		{
+			name: "shared dependency",
+			files: map[string]string{
+				"main.tbd": "import \"a\"\nimport \"b\"\n\nfunc main() {\n\ta.F()\n\tb.G()\n}\n",
+				"a/a.tbd":  "import \"b\"\n\npublic func F() int {\n\treturn b.G()\n}\n",
+				"b/b.tbd":  "public func G() int {\n\treturn 1\n}\n",
+			},
+			order: []string{"b", "a", "main"},
+		},
+		{
+			name: "several files",
+			files: map[string]string{
+				"main.tbd":  "import \"a\"\n\nfunc main() {\n\ta.F()\n}\n",
+				"a/a.tbd":   "public func F() int {\n\treturn g()\n}\n",
+				"a/g.tbd":   "func g() int {\n\treturn 1\n}\n",
+				"a/no.text": "not source",
+			},
+			order: []string{"a", "main"},
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"main.tbd": "import \"a\"\n\nfunc main() {\n}\n",
+				"a/a.tbd":  "import \"b\"\n",
+				"b/b.tbd":  "import \"a\"\n",
+			},
+			err: "import cycle not allowed: a -> b -> a",
+		},
+		{
+			name: "missing module",
+			files: map[string]string{
+				"main.tbd": "import \"a\"\n\nfunc main() {\n}\n",
+			},
+			err: "unable to find module 'a'",
+		},
+		{
+			name: "module without source",
+			files: map[string]string{
+				"main.tbd":  "import \"a\"\n\nfunc main() {\n}\n",
+				"a/no.text": "not source",
+			},
+			err: "module 'a' has no source files",
+		},
+		{
+			name: "private name",
+			files: map[string]string{
+				"main.tbd": "import \"a\"\n\nfunc main() {\n\ta.g()\n}\n",
+				"a/a.tbd":  "func g() int {\n\treturn 1\n}\n",
+			},
+			err: "main.tbd:4:2: cannot refer to private name 'g' of module 'a'",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			for name, src := range test.files {
+				path := filepath.Join(dir, name)
+
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mods, errs := New(dir).Load(filepath.Join(dir, "main.tbd"))
+
+			if test.err == "" {
+				for _, err := range errs {
+					t.Errorf("unexpected error: %s", err)
+				}
+
+				var order []string
+
+				for _, mod := range mods {
+					order = append(order, mod.Path)
+				}
+
+				if !reflect.DeepEqual(order, test.order) {
+					t.Errorf("loaded %v; want %v", order, test.order)
+				}
+
+				return
+			}
+
+			for _, err := range errs {
+				if strings.Contains(err.Error(), test.err) {
+					return
+				}
+			}
+
+			t.Errorf("errors %q; want one containing %q", errs, test.err)
+		})
+	}
+}
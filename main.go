@@ -2,37 +2,27 @@ package main
 
 import (
 	"fmt"
-	"go/token"
-	"io/ioutil"
 	"main/factorio"
-	"main/generator"
-	"main/parser"
+	"main/loader"
 	"os"
 )
 
 
-const hm = 0x1.2p+1
-
 func main() {
-	content, _ := ioutil.ReadFile("./test2.tbd")
-	file := token.NewFileSet().AddFile("blah", 1, len(content))
-
-	p := parser.NewParser(content, file)
+	mods, errs := loader.New(".").Load("./test2.tbd")
 
-	mod := p.ParseModule()
-	
-	m := generator.ProcessModule(mod)
-	// fmt.Println(inspector.Inspect(a))
-
-	if len(m.Errors) > 0 {
-		for _, err := range m.Errors {
-			fmt.Println(err.Format(file))
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Println(err)
 		}
 
 		return
 	}
 
-	// fmt.Println(stringify(m))
+	// The program itself is loaded last.
+	m := *mods[len(mods)-1]
+
+	// fmt.Println(jscompiler.Stringify(mods))
 	// 
 	
 
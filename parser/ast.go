@@ -2,9 +2,12 @@ package parser
 
 import (
 	"fmt"
+	"go/constant"
 	"go/token"
 	"main/inspector"
 	"main/lexer"
+	"path"
+	"strconv"
 	"strings"
 )
 
@@ -49,6 +52,12 @@ type DeclarationNode interface {
 	Name() string
 }
 
+// A top-level declaration, which can be referenced by name.
+type NamedNode interface {
+	TopLevelNode
+	Name() string
+}
+
 // Elements of an array, struct, map, etc.
 // This is either ElementsNode or InvalidNode.
 type ElementListNode interface {
@@ -125,13 +134,15 @@ func (p PropertyAccessNode) InspectCustom() inspector.InspectString {
 
 func (PropertyAccessNode) isValueNode() {}
 
+// Only a name qualified by a module, ie `factorio.Lamp`.
+func (PropertyAccessNode) isTypeNode() {}
+
 // A constant integer value.
 type IntegerNode struct {
 	BaseNode
-	// The value of the number number.
-	Value uint64
-	// The minimum amount of bits required to store the value.
-	Bits uint8
+	// The value of the number, which can be any size: it's only checked against
+	// a type once it's given one, ie `1 << 100 >> 98`.
+	Value constant.Value
 	// The length of the raw integer string.
 	strlen int
 }
@@ -140,8 +151,8 @@ func (i IntegerNode) End() token.Pos {
 	return i.start + token.Pos(i.strlen)
 }
 
-func (i IntegerNode) InspectCustom() uint64 {
-	return i.Value
+func (i IntegerNode) InspectCustom() string {
+	return i.Value.ExactString()
 }
 
 func (IntegerNode) isValueNode() {}
@@ -149,10 +160,9 @@ func (IntegerNode) isValueNode() {}
 // A constant floating-point value.
 type FloatNode struct {
 	BaseNode
-	// The stringified form of the number.
-	Value float64
-	// Whether the float is double precision (64 bit) or single-precision (32 bit)
-	isDouble bool
+	// The exact value of the number, which is only rounded once it's given a
+	// type, ie `0.1` is only a float32 when it's assigned to one.
+	Value constant.Value
 	// The length of the raw float string.
 	strlen int
 }
@@ -161,6 +171,10 @@ func (f FloatNode) End() token.Pos {
 	return f.start + token.Pos(f.strlen)
 }
 
+func (f FloatNode) InspectCustom() string {
+	return f.Value.ExactString()
+}
+
 func (FloatNode) isValueNode() {}
 
 // A constant string value.
@@ -168,8 +182,8 @@ type StringNode struct {
 	BaseNode
 	// The value of the string.
 	Value string
-	// The end of the string.
-	end int
+	// The end of the string (ie, after the closing quote).
+	end token.Pos
 }
 
 func (s StringNode) InspectCustom() string {
@@ -177,12 +191,30 @@ func (s StringNode) InspectCustom() string {
 }
 
 func (s StringNode) End() token.Pos {
-	// + 2 characters for quotes
-	return s.start + token.Pos(len(s.Value))
+	return s.end
 }
 
 func (StringNode) isValueNode() {}
 
+// A constant rune value, ie 'a'.
+type CharNode struct {
+	BaseNode
+	// The value of the rune.
+	Value rune
+	// The length of the raw rune literal.
+	strlen int
+}
+
+func (c CharNode) InspectCustom() inspector.InspectString {
+	return inspector.InspectString(strconv.QuoteRune(c.Value))
+}
+
+func (c CharNode) End() token.Pos {
+	return c.start + token.Pos(c.strlen)
+}
+
+func (CharNode) isValueNode() {}
+
 // A function call.
 type CallNode struct {
 	BaseNode
@@ -190,6 +222,8 @@ type CallNode struct {
 	Callee ValueNode
 	// The arguments the target function is being called with.
 	Arguments []ValueNode
+	// Whether the last argument is spread into a variadic argument, ie `f(a...)`.
+	Spread bool
 	// The end of the function call (ie: the terminating ')').
 	end token.Pos
 }
@@ -211,17 +245,40 @@ func (c CallNode) InspectCustom() inspector.InspectString {
 func (CallNode) isStepNode()  {}
 func (CallNode) isValueNode() {}
 
-// An import to a module or external package.
-// TODO: Do
+// An import of another module, ie `import alias "path"`.
 type ImportDeclarationNode struct {
 	BaseNode
 	// The path of the import.
 	Path string
 	// The alias of the package.  Optional.
 	Alias string
+	end   token.Pos
+}
+
+func (i ImportDeclarationNode) End() token.Pos {
+	return i.end
+}
+
+// The name the module is referred to by: its alias, or otherwise the last
+// element of its path.
+func (i ImportDeclarationNode) Name() string {
+	if i.Alias != "" {
+		return i.Alias
+	}
+
+	return path.Base(i.Path)
+}
+
+func (i ImportDeclarationNode) InspectCustom() inspector.InspectString {
+	if i.Alias != "" {
+		return inspector.InspectString(fmt.Sprintf("import %s %q", i.Alias, i.Path))
+	}
+
+	return inspector.InspectString(fmt.Sprintf("import %q", i.Path))
 }
 
 func (ImportDeclarationNode) isTopLevelNode()    {}
+func (ImportDeclarationNode) isStepNode()        {}
 func (ImportDeclarationNode) isDeclarationNode() {}
 
 // A declaration of a constant value.
@@ -301,21 +358,119 @@ func (v VariableDeclarationNode) Name() string {
 	return v.name
 }
 
+// A declaration of several variables at once, ie `var a, b int = 1, 2` or
+// `a, b := f()`.
+type TupleDeclarationNode struct {
+	BaseNode
+	// The names of the variables.
+	Names []IdentifierNode
+	// The type of every variable (if any).
+	Type TypeNode
+	// The initial values of the variables: one for each variable, a single call
+	// returning a value for each, or none.
+	Values []ValueNode
+	// Whether the declaration is `a, b := ...`, which can also assign to
+	// variables which are already declared.
+	Define bool
+	// The variable declared by `catch err` (if any), which is set to the error
+	// thrown by the call in Values.  Only allowed with `:=`.
+	Catch *IdentifierNode
+}
+
+func (t TupleDeclarationNode) InspectCustom() inspector.InspectString {
+	var str strings.Builder
+
+	if !t.Define {
+		str.WriteString("var ")
+	}
+
+	str.WriteString(inspectList(t.Names))
+
+	if t.Catch != nil {
+		if len(t.Names) > 0 {
+			str.WriteString(", ")
+		}
+
+		str.WriteString("catch " + t.Catch.Target)
+	}
+
+	if t.Type != nil {
+		str.WriteByte(' ')
+		str.WriteString(inspector.Inspect(t.Type))
+	}
+
+	if len(t.Values) > 0 {
+		if t.Define {
+			str.WriteString(" := ")
+		} else {
+			str.WriteString(" = ")
+		}
+
+		str.WriteString(inspectList(t.Values))
+	}
+
+	return inspector.InspectString(str.String())
+}
+
+func (t TupleDeclarationNode) End() token.Pos {
+	switch {
+	case len(t.Values) > 0:
+		return t.Values[len(t.Values)-1].End()
+	case t.Type != nil:
+		return t.Type.End()
+	case t.Catch != nil:
+		return t.Catch.End()
+	}
+
+	return t.Names[len(t.Names)-1].End()
+}
+
+func (TupleDeclarationNode) isStepNode()        {}
+func (TupleDeclarationNode) isTopLevelNode()    {}
+func (TupleDeclarationNode) isDeclarationNode() {}
+
+// The name of the first variable.
+func (t TupleDeclarationNode) Name() string {
+	return t.Names[0].Target
+}
+
+// Inspects nodes as a comma-separated list, ie `a, b`.
+func inspectList[T AstNode](nodes []T) string {
+	strs := make([]string, len(nodes))
+
+	for i, node := range nodes {
+		strs[i] = inspector.Inspect(node)
+	}
+
+	return strings.Join(strs, ", ")
+}
+
 // A declaration of a function argument.
 type ArgumentDeclarationNode struct {
 	BaseNode
-	// The name of the argument.
+	// The name of the argument; empty for unnamed results.
 	name string
-	// The type of the argument.
+	// The type of the argument; nil if it has the type of the next argument, ie
+	// `a, b int`.
 	Type TypeNode
+	// Whether the argument takes any number of values, ie `a ...int`.
+	Variadic bool
 }
 
 func (a ArgumentDeclarationNode) InspectCustom() inspector.InspectString {
-	if a.Type != nil {
-		return inspector.InspectString(fmt.Sprintf("%s %s", a.name, inspector.Inspect(a.Type)))
+	typ := inspector.Inspect(a.Type)
+	if a.Variadic {
+		typ = "..." + typ
+	}
+
+	switch {
+	case a.Type == nil:
+		return inspector.InspectString(a.name)
+	case a.name == "":
+		return inspector.InspectString(typ)
 	}
 
-	return inspector.InspectString(a.name)
+	return inspector.InspectString(fmt.Sprintf("%s %s", a.name, typ))
 }
 
 func (a ArgumentDeclarationNode) End() token.Pos {
@@ -367,8 +522,8 @@ type FunctionNode struct {
 	// The function's block.
 	Block BlockNode
 
-	// The return type of the function.
-	Returns IdentifierNode
+	// The results of the function (if any).
+	Results []ArgumentDeclarationNode
 }
 
 func (f FunctionNode) End() token.Pos {
@@ -380,9 +535,27 @@ func (FunctionNode) isValueNode()    {}
 func (FunctionNode) isFunctionNode() {}
 
 func (f FunctionNode) InspectCustom() inspector.InspectString {
+	if len(f.Results) > 0 {
+		return inspector.InspectString(fmt.Sprintf("%s %s {\n%s\n}", inspector.Inspect(f.Arguments), inspectResults(f.Results), inspector.Inspect(f.Block)))
+	}
+
 	return inspector.InspectString(fmt.Sprintf("%s {\n%s\n}", inspector.Inspect(f.Arguments), inspector.Inspect(f.Block)))
 }
 
+// Formats the results of a function, ie `int` or `(a, b int)`.
+func inspectResults(results []ArgumentDeclarationNode) string {
+	if len(results) == 1 && results[0].name == "" {
+		return inspector.Inspect(results[0])
+	}
+
+	strs := make([]string, len(results))
+	for i, result := range results {
+		strs[i] = inspector.Inspect(result)
+	}
+
+	return "(" + strings.Join(strs, ", ") + ")"
+}
+
 // A declaration of a top-level function.
 type ModuleFunctionDeclarationNode struct {
 	BaseNode
@@ -420,7 +593,7 @@ type MethodDeclarationNode struct {
 }
 
 func (m MethodDeclarationNode) Name() string {
-	return "<method>"
+	return m.name
 }
 
 func (m MethodDeclarationNode) InspectCustom() inspector.InspectString {
@@ -437,11 +610,180 @@ func (m MethodDeclarationNode) End() token.Pos {
 func (MethodDeclarationNode) isTopLevelNode()    {}
 func (MethodDeclarationNode) isDeclarationNode() {}
 
+// A declaration of an enum type.
+type EnumDeclarationNode struct {
+	BaseNode
+	// The name of the enum.
+	name string
+	// The members of the enum, in the order they were declared.
+	Members []IdentifierNode
+	// The closing '}'
+	end token.Pos
+}
+
+func (e EnumDeclarationNode) InspectCustom() inspector.InspectString {
+	members := make([]string, len(e.Members))
+
+	for i, member := range e.Members {
+		members[i] = "\t" + member.Target
+	}
+
+	return inspector.InspectString(fmt.Sprintf("enum %s {\n%s\n}", e.name, strings.Join(members, "\n")))
+}
+
+func (e EnumDeclarationNode) End() token.Pos {
+	return e.end
+}
+
+func (e EnumDeclarationNode) Name() string {
+	return e.name
+}
+
+func (EnumDeclarationNode) isTopLevelNode()    {}
+func (EnumDeclarationNode) isDeclarationNode() {}
+
+// A declaration of an interface type.
+type InterfaceDeclarationNode struct {
+	BaseNode
+	// The name of the interface.
+	name string
+	// The methods of the interface, in the order they were declared.
+	Methods []InterfaceMethodNode
+	// The interfaces whose methods are included in this one.
+	Embedded []IdentifierNode
+	// The closing '}'
+	end token.Pos
+}
+
+func (i InterfaceDeclarationNode) InspectCustom() inspector.InspectString {
+	lines := make([]string, 0, len(i.Embedded)+len(i.Methods))
+
+	for _, embedded := range i.Embedded {
+		lines = append(lines, "\t"+embedded.Target)
+	}
+
+	for _, method := range i.Methods {
+		lines = append(lines, "\t"+inspector.Inspect(method))
+	}
+
+	return inspector.InspectString(fmt.Sprintf("interface %s {\n%s\n}", i.name, strings.Join(lines, "\n")))
+}
+
+func (i InterfaceDeclarationNode) End() token.Pos {
+	return i.end
+}
+
+func (i InterfaceDeclarationNode) Name() string {
+	return i.name
+}
+
+func (InterfaceDeclarationNode) isTopLevelNode()    {}
+func (InterfaceDeclarationNode) isDeclarationNode() {}
+
+// A method of an interface, ie `area() int`.
+type InterfaceMethodNode struct {
+	BaseNode
+	// The name of the method.
+	name string
+	// The arguments of the method.
+	Arguments ArgumentDeclarationsNode
+	// The return type of the method.
+	Results []ArgumentDeclarationNode
+	end     token.Pos
+}
+
+func (m InterfaceMethodNode) InspectCustom() inspector.InspectString {
+	if len(m.Results) > 0 {
+		return inspector.InspectString(fmt.Sprintf("%s%s %s", m.name, inspector.Inspect(m.Arguments), inspectResults(m.Results)))
+	}
+
+	return inspector.InspectString(m.name + inspector.Inspect(m.Arguments))
+}
+
+func (m InterfaceMethodNode) End() token.Pos {
+	return m.end
+}
+
+func (m InterfaceMethodNode) Name() string {
+	return m.name
+}
+
+// A declaration of a struct type.
+type StructDeclarationNode struct {
+	BaseNode
+	// The name of the struct.
+	name string
+	// The fields of the struct, in the order they were declared.
+	Fields []FieldDeclarationNode
+	// The closing '}'
+	end token.Pos
+}
+
+func (s StructDeclarationNode) InspectCustom() inspector.InspectString {
+	fields := make([]string, len(s.Fields))
+
+	for i, field := range s.Fields {
+		fields[i] = "\t" + inspector.Inspect(field)
+	}
+
+	return inspector.InspectString(fmt.Sprintf("struct %s {\n%s\n}", s.name, strings.Join(fields, "\n")))
+}
+
+func (s StructDeclarationNode) End() token.Pos {
+	return s.end
+}
+
+func (StructDeclarationNode) isTopLevelNode()    {}
+func (StructDeclarationNode) isDeclarationNode() {}
+
+// The name of the struct.
+func (s StructDeclarationNode) Name() string {
+	return s.name
+}
+
+// A field of a struct.
+type FieldDeclarationNode struct {
+	BaseNode
+	// The name of the field; empty if the field is embedded.
+	name string
+	// The type of the field.
+	Type TypeNode
+}
+
+func (f FieldDeclarationNode) InspectCustom() inspector.InspectString {
+	if f.IsEmbedded() {
+		return inspector.InspectString(inspector.Inspect(f.Type))
+	}
+
+	return inspector.InspectString(fmt.Sprintf("%s %s", f.name, inspector.Inspect(f.Type)))
+}
+
+func (f FieldDeclarationNode) End() token.Pos {
+	return f.Type.End()
+}
+
+func (FieldDeclarationNode) isDeclarationNode() {}
+
+// The name of the field.  Embedded fields are named after their type.
+func (f FieldDeclarationNode) Name() string {
+	if f.IsEmbedded() {
+		return f.Type.(IdentifierNode).Target
+	}
+
+	return f.name
+}
+
+// Whether the field is embedded, ie `T` rather than `name T`.
+func (f FieldDeclarationNode) IsEmbedded() bool {
+	return f.name == ""
+}
+
 // An assignment to a variable or property.
 type AssignmentNode struct {
 	BaseNode
-	// The reference to the item being assigned a value.
-	Assignee IdentifierNode
+	// The reference to the item being assigned a value (an IdentifierNode or
+	// PropertyAccessNode).
+	Assignee ValueNode
 	// The value which is to be assigned to the variable.
 	Value ValueNode
 	end   token.Pos
@@ -455,6 +797,38 @@ func (a AssignmentNode) End() token.Pos {
 }
 func (AssignmentNode) isStepNode() {}
 
+// An assignment to several targets at once, ie `a, b = b, a`.
+type TupleAssignmentNode struct {
+	BaseNode
+	// The references being assigned to (IdentifierNodes or
+	// PropertyAccessNodes).
+	Assignees []ValueNode
+	// The values being assigned: one for each target, or a single call
+	// returning a value for each.
+	Values []ValueNode
+	// Where `catch err` stores the error thrown by the call in Values (if
+	// any).
+	Catch ValueNode
+}
+
+func (t TupleAssignmentNode) InspectCustom() inspector.InspectString {
+	targets := inspectList(t.Assignees)
+
+	if t.Catch != nil {
+		if targets != "" {
+			targets += ", "
+		}
+
+		targets += "catch " + inspector.Inspect(t.Catch)
+	}
+
+	return inspector.InspectString(targets + " = " + inspectList(t.Values))
+}
+func (t TupleAssignmentNode) End() token.Pos {
+	return t.Values[len(t.Values)-1].End()
+}
+func (TupleAssignmentNode) isStepNode() {}
+
 // An operation consisting of left and right sides.
 type BinaryOperationNode struct {
 	BaseNode
@@ -612,6 +986,47 @@ func (a ArrayValueNode) End() token.Pos {
 }
 func (ArrayValueNode) isValueNode() {}
 
+// A composite literal of a named type, ie `T{a: 1}`.
+type CompositeValueNode struct {
+	BaseNode
+
+	// The type of the value.
+	Type TypeNode
+	// The elements of the value.
+	Elements ElementListNode
+}
+
+func (c CompositeValueNode) InspectCustom() inspector.InspectString {
+	return inspector.InspectString(inspector.Inspect(c.Type) + inspector.Inspect(c.Elements))
+}
+
+func (c CompositeValueNode) End() token.Pos {
+	return c.Elements.End()
+}
+
+func (CompositeValueNode) isValueNode() {}
+
+// A type assertion, ie `a.(T)`.
+type TypeAssertionNode struct {
+	BaseNode
+	// The interface value being asserted.
+	Value ValueNode
+	// The type it's asserted to have.
+	Type TypeNode
+	// The closing ')'
+	end token.Pos
+}
+
+func (t TypeAssertionNode) InspectCustom() inspector.InspectString {
+	return inspector.InspectString(fmt.Sprintf("%s.(%s)", inspector.Inspect(t.Value), inspector.Inspect(t.Type)))
+}
+
+func (t TypeAssertionNode) End() token.Pos {
+	return t.end
+}
+
+func (TypeAssertionNode) isValueNode() {}
+
 // Reperents the literal value `nil`
 type NilNode struct {
 	BaseNode
@@ -620,6 +1035,9 @@ type NilNode struct {
 func (n NilNode) End() token.Pos {
 	return n.start + token.Pos(len(lexer.NIL.String()))
 }
+func (NilNode) InspectCustom() inspector.InspectString {
+	return "nil"
+}
 
 func (NilNode) isValueNode() {}
 
@@ -691,18 +1109,56 @@ func (b BlockNode) isBlockNode() {}
 // A return statement.
 type ReturnNode struct {
 	BaseNode
-	// The value returned.
-	Value ValueNode
+	// The values returned (if any).
+	Values []ValueNode
 }
 
 func (r ReturnNode) End() token.Pos {
-	return r.Value.End()
+	if len(r.Values) == 0 {
+		return r.start + token.Pos(len(lexer.RETURN.String()))
+	}
+
+	return r.Values[len(r.Values)-1].End()
 }
 func (r ReturnNode) InspectCustom() inspector.InspectString {
-	return inspector.InspectString("return " + inspector.Inspect(r.Value))
+	if len(r.Values) == 0 {
+		return "return"
+	}
+
+	return inspector.InspectString("return " + inspectList(r.Values))
 }
 func (ReturnNode) isStepNode() {}
 
+// A start statement, ie `start f(x)`.
+type StartNode struct {
+	BaseNode
+	// The call which is started.
+	Call CallNode
+}
+
+func (s StartNode) End() token.Pos {
+	return s.Call.End()
+}
+func (s StartNode) InspectCustom() inspector.InspectString {
+	return inspector.InspectString("start " + inspector.Inspect(s.Call))
+}
+func (StartNode) isStepNode() {}
+
+// A throw statement, ie `throw err`.
+type ThrowNode struct {
+	BaseNode
+	// The error thrown.
+	Value ValueNode
+}
+
+func (t ThrowNode) End() token.Pos {
+	return t.Value.End()
+}
+func (t ThrowNode) InspectCustom() inspector.InspectString {
+	return inspector.InspectString("throw " + inspector.Inspect(t.Value))
+}
+func (ThrowNode) isStepNode() {}
+
 // A module.
 type ModuleNode struct {
 	Nodes []TopLevelNode
@@ -737,6 +1193,33 @@ func (i IndexNode) End() token.Pos {
 }
 func (IndexNode) isValueNode() {}
 
+// A slice of a value, ie `a[1:2]`.
+type SliceNode struct {
+	BaseNode
+	// The value being sliced (presumably an array or slice).
+	SliceOf ValueNode
+	// The bounds of the slice; either can be nil, ie `a[:2]` or `a[1:]`.
+	Low, High ValueNode
+
+	end token.Pos
+}
+
+func (s SliceNode) InspectCustom() inspector.InspectString {
+	bound := func(node ValueNode) string {
+		if node == nil {
+			return ""
+		}
+
+		return string(inspector.Inspect(node))
+	}
+
+	return inspector.InspectString(fmt.Sprintf("%s[%s:%s]", inspector.Inspect(s.SliceOf), bound(s.Low), bound(s.High)))
+}
+func (s SliceNode) End() token.Pos {
+	return s.end
+}
+func (SliceNode) isValueNode() {}
+
 // Marks a declaration as public.
 type PublicNode struct {
 	BaseNode
@@ -757,7 +1240,10 @@ func (p PublicNode) InspectCustom() inspector.InspectString {
 // An if statment.
 type IfNode struct {
 	BaseNode
-	// The declaration which should be public.
+	// The statement executed before the condition is evaluated (if any).  Its
+	// variables are in scope for every following branch.
+	Init StepNode
+	// The condition which decides which block is evaluated.
 	Condition ValueNode
 	// The block which should be evaluated provide that the condition is truthy.
 	Then BlockNode
@@ -768,11 +1254,147 @@ type IfNode struct {
 }
 
 func (i IfNode) End() token.Pos {
-	if i.Else.start == 0 {
-		return i.Then.end
+	switch {
+	case i.Else != nil:
+		return i.Else.end
+	case len(i.ElseIf) > 0:
+		return i.ElseIf[len(i.ElseIf)-1].End()
 	}
 
-	return i.Else.end
+	return i.Then.end
 }
 
 func (IfNode) isStepNode() {}
+
+// A for loop.  The loop is infinite if Condition is nil.
+type ForNode struct {
+	BaseNode
+	// The statement executed before the first iteration (if any).
+	Init StepNode
+	// The condition checked prior to each iteration.
+	Condition ValueNode
+	// The statement executed after each iteration (if any).
+	Post StepNode
+	// The loop's body.
+	Block BlockNode
+}
+
+func (f ForNode) End() token.Pos {
+	return f.Block.end
+}
+
+func (f ForNode) InspectCustom() inspector.InspectString {
+	var head []string
+
+	if f.Init != nil || f.Post != nil {
+		head = []string{"", "", ""}
+
+		if f.Init != nil {
+			head[0] = inspector.Inspect(f.Init)
+		}
+		if f.Post != nil {
+			head[2] = inspector.Inspect(f.Post)
+		}
+	} else {
+		head = []string{""}
+	}
+
+	if f.Condition != nil {
+		head[len(head)/2] = inspector.Inspect(f.Condition)
+	}
+
+	if head := strings.Join(head, "; "); head != "" {
+		return inspector.InspectString(fmt.Sprintf("for %s {\n%s\n}", head, inspector.Inspect(f.Block)))
+	}
+
+	return inspector.InspectString(fmt.Sprintf("for {\n%s\n}", inspector.Inspect(f.Block)))
+}
+
+func (ForNode) isStepNode() {}
+
+// A `break`, `continue` or `fallthrough` statement.
+type BranchNode struct {
+	BaseNode
+	// Either lexer.BREAK, lexer.CONTINUE or lexer.FALLTHROUGH
+	Token lexer.Token
+}
+
+func (b BranchNode) End() token.Pos {
+	return b.start + token.Pos(len(b.Token.String()))
+}
+
+func (b BranchNode) InspectCustom() inspector.InspectString {
+	return inspector.InspectString(b.Token.String())
+}
+
+func (BranchNode) isStepNode() {}
+
+// A switch statement.  Tag is nil for a tagless switch, whose cases are
+// conditions.
+type SwitchNode struct {
+	BaseNode
+	// The statement executed before the tag is evaluated (if any).
+	Init StepNode
+	// The value compared against each case.
+	Tag   ValueNode
+	Cases []CaseNode
+	end   token.Pos
+}
+
+func (s SwitchNode) End() token.Pos {
+	return s.end
+}
+
+func (s SwitchNode) InspectCustom() inspector.InspectString {
+	var head []string
+
+	if s.Init != nil {
+		head = append(head, inspector.Inspect(s.Init))
+	}
+	if s.Tag != nil {
+		head = append(head, inspector.Inspect(s.Tag))
+	} else if s.Init != nil {
+		head = append(head, "")
+	}
+
+	cases := make([]string, len(s.Cases))
+
+	for i, c := range s.Cases {
+		cases[i] = inspector.Inspect(c)
+	}
+
+	if len(head) > 0 {
+		return inspector.InspectString(fmt.Sprintf("switch %s {\n%s\n}", strings.Join(head, "; "), strings.Join(cases, "\n")))
+	}
+
+	return inspector.InspectString(fmt.Sprintf("switch {\n%s\n}", strings.Join(cases, "\n")))
+}
+
+func (SwitchNode) isStepNode() {}
+
+// A case of a switch, along with the statements up to the next one.  Values is
+// nil for `default`.
+type CaseNode struct {
+	BaseNode
+	Values []ValueNode
+	// The case's statements, which start at the `:` (there are no braces).
+	Body BlockNode
+}
+
+func (c CaseNode) End() token.Pos {
+	return c.Body.end
+}
+
+func (c CaseNode) InspectCustom() inspector.InspectString {
+	if c.Values == nil {
+		return inspector.InspectString(fmt.Sprintf("default:\n%s", inspector.Inspect(c.Body)))
+	}
+
+	values := make([]string, len(c.Values))
+
+	for i, v := range c.Values {
+		values[i] = inspector.Inspect(v)
+	}
+
+	return inspector.InspectString(fmt.Sprintf("case %s:\n%s", strings.Join(values, ", "), inspector.Inspect(c.Body)))
+}
@@ -1,14 +1,13 @@
 package parser
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"go/constant"
 	"go/token"
-	"main/inspector"
 	"main/lexer"
-	"math/bits"
-	"strconv"
+	"sort"
+	"strings"
 )
 
 type kind int
@@ -21,10 +20,19 @@ type Parser struct {
 	token  lexer.Token
 	raw    string
 	pos    token.Pos
+
+	// Syntax errors recorded while parsing.
+	errors []lexer.PositionError
+
+	// < 0 while parsing the header of an `if` or `for`, where a `{` starts the
+	// block rather than a composite literal; > 0 inside parentheses.
+	exprLev int
+	// The number of `{` consumed which haven't been closed yet.
+	braces int
 }
 
 func NewParser(src []byte, file *token.File) *Parser {
-	scanner, err := lexer.NewScanner(src, file)
+	scanner, err := lexer.NewScanner(src, file, 0)
 
 	// TODO: handle this in a better way
 	if err != nil {
@@ -41,6 +49,13 @@ func NewParser(src []byte, file *token.File) *Parser {
 }
 
 func (p *Parser) next() {
+	switch p.token {
+	case lexer.OBRACE:
+		p.braces++
+	case lexer.CBRACE:
+		p.braces--
+	}
+
 	p.token, p.raw, p.pos = p.sc.Next()
 }
 
@@ -49,8 +64,8 @@ func (p *Parser) currentTokenString() string {
 		return p.token.String()
 	}
 
-	if p.token == lexer.STRING {
-		return "\"" + p.raw + "\""
+	if p.token == lexer.SEMICOLON {
+		return "newline"
 	}
 
 	return p.raw
@@ -69,27 +84,134 @@ var (
 
 func (p *Parser) tokenEnd() token.Pos {
 	switch p.token {
-	case lexer.INT, lexer.FLOAT, lexer.IDENTIFIER:
+	case lexer.INT, lexer.FLOAT, lexer.CHAR, lexer.STRING, lexer.IDENTIFIER:
 		return p.pos + token.Pos(len(p.raw))
-	case lexer.STRING:
-		return p.pos + token.Pos(len(p.raw)) + 2
 	}
 
 	return p.pos + token.Pos(len(p.token.String()))
 }
 
+// A syntax error.  These are panicked by the parsing functions and recovered at
+// the nearest statement or top-level declaration (see handleError).
 type parserError struct {
-	error
+	lexer.PositionError
+	// Whether the error was caused by an invalid token, which the scanner has
+	// already reported.
+	isDuplicate bool
 }
 
 func (p *Parser) err(start token.Pos, str string) parserError {
+	return p.errf(start, "%s", str)
+}
+
+func (p *Parser) errf(start token.Pos, str string, values ...interface{}) parserError {
 	return parserError{
-		fmt.Errorf("err at %s: %s", p.positonString(), str),
+		PositionError: p.sc.Errorf(start, str, values...),
+		isDuplicate:   p.token == lexer.INVALID,
 	}
 }
 
-func (p *Parser) errf(start token.Pos, str string, values ...interface{}) parserError {
-	return parserError{fmt.Errorf("err at %s: %s", p.positonString(), fmt.Sprintf(str, values...))}
+// Handles a value recovered while parsing the node starting at start.  If it's
+// a syntax error, it's recorded and sync is used to skip to the next node.
+func (p *Parser) handleError(recovered any, start token.Pos, sync func()) InvalidNode {
+	err, ok := recovered.(parserError)
+
+	if !ok {
+		panic(recovered)
+	}
+
+	p.report(err)
+
+	// Always skip at least one token, otherwise we'll fail on it again.
+	if p.pos == start && p.token != lexer.EOF {
+		p.next()
+	}
+
+	sync()
+
+	return InvalidNode{
+		BaseNode: p.nodeAt(start),
+		err:      err.PositionError,
+		end:      p.pos,
+	}
+}
+
+// Records err without interrupting the parser.
+func (p *Parser) report(err parserError) {
+	if !err.isDuplicate {
+		p.errors = append(p.errors, err.PositionError)
+	}
+}
+
+// Skips to the start of the next statement: past a `;`, or up to a statement
+// keyword or the `}` closing the current block.  depth is the number of braces
+// opened by the statement so far (ie if it failed inside a composite literal).
+func (p *Parser) syncStep(depth int) {
+	for ; p.token != lexer.EOF; p.next() {
+		switch p.token {
+		case lexer.OBRACE:
+			depth++
+			continue
+		case lexer.CBRACE:
+			if depth == 0 {
+				return
+			}
+
+			depth--
+			continue
+		}
+
+		if depth > 0 {
+			continue
+		}
+
+		switch p.token {
+		case lexer.SEMICOLON:
+			p.next()
+			return
+		case lexer.VAR, lexer.CONST, lexer.IF, lexer.FOR, lexer.SWITCH, lexer.CASE, lexer.DEFAULT, lexer.RETURN,
+			lexer.BREAK, lexer.CONTINUE, lexer.FALLTHROUGH:
+			return
+		}
+	}
+}
+
+// Skips to the start of the next top-level declaration.
+func (p *Parser) syncTopLevel() {
+	for depth := 0; p.token != lexer.EOF; p.next() {
+		switch p.token {
+		case lexer.OBRACE:
+			depth++
+		case lexer.CBRACE:
+			if depth == 0 {
+				p.next()
+				return
+			}
+
+			depth--
+		case lexer.SEMICOLON:
+			if depth == 0 {
+				p.next()
+				return
+			}
+		case lexer.FUNC, lexer.VAR, lexer.CONST, lexer.STRUCT, lexer.ENUM, lexer.INTERFACE, lexer.PUBLIC, lexer.IMPORT:
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// Every error found while parsing, including those from the scanner, in the
+// order they appear in the source.
+func (p *Parser) diagnostics() []lexer.PositionError {
+	errs := append(append([]lexer.PositionError{}, p.sc.Errors...), p.errors...)
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Offset < errs[j].Offset
+	})
+
+	return errs
 }
 
 const expr = `
@@ -134,7 +256,7 @@ func (p *Parser) assertValue(v ValueNode) ValueNode {
 
 func (p *Parser) parseIdentifier() (node IdentifierNode) {
 	if p.token != lexer.IDENTIFIER {
-		panic(fmt.Errorf("not an identifier: %s", p.currentTokenString()))
+		panic(p.errf(p.pos, "expected identifier; received '%s'", p.currentTokenString()))
 	}
 
 	node = IdentifierNode{
@@ -151,28 +273,33 @@ func (p *Parser) nodeHere() BaseNode {
 }
 
 func (p *Parser) parseInt() ValueNode {
-	var err error
 	node := IntegerNode{BaseNode: p.nodeHere(), strlen: len(p.raw)}
+	raw := p.raw
 
-	if node.Value, err = strconv.ParseUint(p.raw, 0, 64); err != nil {
-		panic(p.errf(p.pos, "unable to parse integer '%s': %s", p.currentTokenString(), err.Error()))
+	// go/constant handles go's prefixes, but `0d` (explicitly decimal) is our
+	// own.
+	if len(raw) > 2 && raw[0] == '0' && (raw[1] == 'd' || raw[1] == 'D') {
+		raw = strings.TrimLeft(strings.ReplaceAll(raw[2:], "_", ""), "0")
+
+		if raw == "" {
+			raw = "0"
+		}
+	}
+
+	if node.Value = constant.MakeFromLiteral(raw, token.INT, 0); node.Value.Kind() != constant.Int {
+		panic(p.errf(p.pos, "unable to parse integer '%s'", p.currentTokenString()))
 	}
-	p.next()
 
-	node.Bits = uint8(bits.Len64(node.Value))
+	p.next()
 
 	return node
 }
 
 func (p *Parser) parseFloat() ValueNode {
-	var err error
-	node := FloatNode{BaseNode: p.nodeHere()}
+	node := FloatNode{BaseNode: p.nodeHere(), strlen: len(p.raw)}
 
-	if node.Value, err = strconv.ParseFloat(p.raw, 32); err != nil {
-		if node.Value, err = strconv.ParseFloat(p.raw, 64); err != nil {
-			panic(p.errf(p.pos, "unable to parse float '%s': %s", p.currentTokenString(), err.Error()))
-		}
-		node.isDouble = true
+	if node.Value = constant.MakeFromLiteral(p.raw, token.FLOAT, 0); node.Value.Kind() != constant.Float {
+		panic(p.errf(p.pos, "unable to parse float '%s'", p.currentTokenString()))
 	}
 
 	p.next()
@@ -181,9 +308,10 @@ func (p *Parser) parseFloat() ValueNode {
 }
 
 func (p *Parser) parseString() ValueNode {
-	node := StringNode{BaseNode: p.nodeHere()}
+	var err error
+	node := StringNode{BaseNode: p.nodeHere(), end: p.tokenEnd()}
 
-	if err := json.Unmarshal([]byte(p.raw), &node.Value); err != nil {
+	if node.Value, err = lexer.Unquote(p.raw); err != nil {
 		panic(p.errf(p.pos, "error while parsing string: %s", err.Error()))
 	}
 
@@ -191,12 +319,28 @@ func (p *Parser) parseString() ValueNode {
 	return node
 }
 
+func (p *Parser) parseChar() ValueNode {
+	node := CharNode{BaseNode: p.nodeHere(), strlen: len(p.raw)}
+
+	var err error
+
+	if node.Value, err = lexer.UnquoteChar(p.raw); err != nil {
+		panic(p.errf(p.pos, "error while parsing rune: %s", err.Error()))
+	}
+
+	p.next()
+
+	return node
+}
+
 func (p *Parser) parseValue() ValueNode {
 	switch p.token {
 	case lexer.IDENTIFIER:
 		return p.parseIdentifier()
 	case lexer.STRING:
 		return p.parseString()
+	case lexer.CHAR:
+		return p.parseChar()
 	case lexer.INT:
 		return p.parseInt()
 	case lexer.FLOAT:
@@ -207,7 +351,7 @@ func (p *Parser) parseValue() ValueNode {
 }
 
 func (p *Parser) todo() InvalidNode {
-	panic(errors.New("todo"))
+	panic(p.errf(p.pos, "not supported yet: '%s'", p.currentTokenString()))
 }
 
 func (p *Parser) parseType() TypeNode {
@@ -215,7 +359,7 @@ func (p *Parser) parseType() TypeNode {
 	case lexer.OBRACK:
 		return p.parseSliceOrArrayPrefix()
 	case lexer.IDENTIFIER:
-		return p.parseIdentifier()
+		return p.parseTypeName()
 	case lexer.STRUCT, lexer.INTERFACE:
 		return p.todo()
 	}
@@ -223,10 +367,32 @@ func (p *Parser) parseType() TypeNode {
 	panic(p.errf(p.pos, "not a type: %s", p.currentTokenString()))
 }
 
+// Parses a type's name, which is either an identifier or a name qualified by a
+// module, ie `factorio.Lamp`.
+func (p *Parser) parseTypeName() TypeNode {
+	ident := p.parseIdentifier()
+
+	if p.token != lexer.PERIOD {
+		return ident
+	}
+
+	p.next()
+
+	if p.token != lexer.IDENTIFIER {
+		panic(p.errf(p.pos, "expected name after '%s.'; received '%s'", ident.Target, p.currentTokenString()))
+	}
+
+	return PropertyAccessNode{
+		BaseNode:   ident.BaseNode,
+		PropertyOf: ident,
+		Property:   p.parseIdentifier(),
+	}
+}
+
 // parses a slice or array.
 func (p *Parser) parseSliceOrArrayPrefix() TypeNode {
 	if p.token != lexer.OBRACK {
-		panic(fmt.Errorf("expected bracket at %s", p.positonString()))
+		panic(p.errf(p.pos, "expected opening bracket '['; received '%s'", p.currentTokenString()))
 	}
 	start := p.pos
 	p.next()
@@ -274,21 +440,28 @@ func (p *Parser) parseSliceOrArrayPrefix() TypeNode {
 
 func (p *Parser) parseKeyedElements(allowUnkeyed bool) ElementListNode {
 	if p.token != lexer.OBRACE {
-		panic(fmt.Errorf("unable to parse keyed elements at %s", p.positonString()))
+		panic(p.errf(p.pos, "expected opening brace '{'; received '%s'", p.currentTokenString()))
 	}
 	start := p.pos
 	p.next()
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
 	// {}
-	if p.token == lexer.CBRACK {
-		return ElementsNode{
+	if p.token == lexer.CBRACE {
+		node := ElementsNode{
 			BaseNode: p.nodeAt(start),
 			Elements: []ElementNode{},
+			end:      p.pos + 1,
 		}
+		p.next()
+
+		return node
 	}
 
 	var (
 		elemStart = p.pos
-		key       = p.parseExpression()
+		key       = p.parseElementValue()
 	)
 
 	// {value,
@@ -315,22 +488,27 @@ func (p *Parser) parseKeyedElements(allowUnkeyed bool) ElementListNode {
 	// {value}
 	if p.token == lexer.CBRACE {
 		if allowUnkeyed {
-			p.next()
-
-			return ElementsNode{
+			node := ElementsNode{
 				BaseNode: p.nodeAt(start),
 				Elements: []ElementNode{{
 					BaseNode: p.nodeAt(elemStart),
 					Value:    key,
 				}},
+				end: p.pos + 1,
 			}
+			p.next()
+
+			return node
 		}
 
 		panic(p.err(elemStart, "expected a keyed element"))
 	}
 
-	if p.token != lexer.COLON {
+	if p.token == lexer.SEMICOLON {
+		panic(p.err(p.pos, "expected ',' before newline"))
+	}
 
+	if p.token != lexer.COLON {
 		panic(p.errf(p.pos, "expected colon; received: '%s'", p.currentTokenString()))
 	}
 
@@ -338,23 +516,33 @@ func (p *Parser) parseKeyedElements(allowUnkeyed bool) ElementListNode {
 	elems := []ElementNode{{
 		BaseNode: p.nodeAt(elemStart),
 		Key:      key,
-		Value:    p.parseExpression(),
+		Value:    p.parseElementValue(),
 	}}
 
 	for {
 		switch p.token {
 		case lexer.CBRACE:
-			p.next()
-			return ElementsNode{
+			node := ElementsNode{
 				BaseNode: p.nodeAt(start),
 				Elements: elems,
+				end:      p.pos + 1,
 			}
+			p.next()
+
+			return node
 		case lexer.COMMA:
 			p.next()
+		case lexer.SEMICOLON:
+			panic(p.err(p.pos, "expected ',' before newline"))
 		default:
 			panic(p.errf(p.pos, "unexpected token: '%s'", p.currentTokenString()))
 		}
 
+		// trailing comma, ie `{\n\ta: 1,\n}`
+		if p.token == lexer.CBRACE {
+			continue
+		}
+
 		elemStart = p.pos
 		key = p.parseExpression()
 
@@ -366,7 +554,7 @@ func (p *Parser) parseKeyedElements(allowUnkeyed bool) ElementListNode {
 		elems = append(elems, ElementNode{
 			BaseNode: p.nodeAt(elemStart),
 			Key:      key,
-			Value:    p.parseExpression(),
+			Value:    p.parseElementValue(),
 		})
 	}
 }
@@ -375,12 +563,16 @@ func (p *Parser) parseUnkeyedElements() ElementListNode {
 	node := ElementsNode{}
 
 	for {
+		// trailing comma
 		if p.token == lexer.CBRACE {
+			node.end = p.pos + 1
+			p.next()
+
 			return node
 		}
 
 		elemStart := p.pos
-		val := p.parseExpression()
+		val := p.parseElementValue()
 
 		switch p.token {
 		case lexer.COMMA:
@@ -395,10 +587,11 @@ func (p *Parser) parseUnkeyedElements() ElementListNode {
 				BaseNode: p.nodeAt(elemStart),
 				Value:    val,
 			})
+			node.end = p.pos + 1
 			p.next()
 			return node
 		case lexer.SEMICOLON:
-			panic(p.err(p.pos, "expected all elements to be positional"))
+			panic(p.err(p.pos, "expected ',' before newline"))
 		default:
 			panic(p.errf(p.pos, "unexpected token: '%s'", p.currentTokenString()))
 		}
@@ -420,6 +613,7 @@ func (p *Parser) parseSliceOrArray() ValueNode {
 	if p.token == lexer.OBRACE {
 		if prefix, ok := prefix.(SlicePrefixNode); ok {
 			return SliceValueNode{
+				BaseNode: p.nodeAt(start),
 				Prefix:   prefix,
 				Elements: p.parseKeyedElements(true),
 			}
@@ -437,6 +631,8 @@ func (p *Parser) parseSliceOrArray() ValueNode {
 	panic(p.errf(p.pos, "unexpected token: '%s'", p.currentTokenString()))
 }
 
+// Whether the current statement ended.  Newlines are read as semicolons by the
+// scanner; the semicolon can be omitted before a closing `}`.
 func (p *Parser) didTerminate() bool {
 	switch p.token {
 	// TODO: don't exit on eof; this is primarily for testing.
@@ -444,20 +640,9 @@ func (p *Parser) didTerminate() bool {
 		return true
 	}
 
-	if !p.token.IsOperator() && p.sc.DidReadNewline() {
-		return true
-	}
-
 	return false
 }
 
-func (p *Parser) assertTerminator(n StepNode) StepNode {
-	if p.didTerminate() {
-		return n
-	}
-	panic(p.err(p.pos, "expected terminator"))
-}
-
 func (p *Parser) parseBinaryExpr(prec1 int) ValueNode {
 	start := p.pos
 	left := p.parseUnaryExpression()
@@ -501,16 +686,8 @@ func (p *Parser) parseSuffixOperation(left ValueNode) ValueNode {
 
 func (p *Parser) parseUnaryExpression() ValueNode {
 	op, start := p.token, p.pos
-	switch p.token {
-	case lexer.ADD, lexer.SUB, lexer.TILDE, lexer.INCR, lexer.DECR, lexer.NOT:
-		p.next()
-
-		return UnaryOperationNode{
-			BaseNode: p.nodeAt(start),
-			Operator: op,
-			Operand:  p.parseUnaryExpression(),
-		}
-	case lexer.AND:
+	switch {
+	case p.token == lexer.AND:
 		p.next()
 
 		// this will break with stuff like &[]int
@@ -523,7 +700,7 @@ func (p *Parser) parseUnaryExpression() ValueNode {
 			Operator: op,
 			Operand:  p.parseUnaryExpression(),
 		}
-	case lexer.MUL:
+	case p.token.IsUnaryOperator():
 		p.next()
 
 		return UnaryOperationNode{
@@ -538,11 +715,13 @@ func (p *Parser) parseUnaryExpression() ValueNode {
 
 func (p *Parser) parseParenthesizedExpression() ValueNode {
 	if p.token != lexer.OPAREN {
-		panic(fmt.Errorf("expected to be called at a parenthesis %s", p.positonString()))
+		panic(p.errf(p.pos, "expected opening parenthesis '('; received '%s'", p.currentTokenString()))
 	}
 
 	p.next()
+	p.exprLev++
 	expr := p.parseExpression()
+	p.exprLev--
 
 	if p.token != lexer.CPAREN {
 		panic(p.err(p.pos, "expected closing parenthesis"))
@@ -562,6 +741,13 @@ func (p *Parser) parseOperand() ValueNode {
 		return p.parseParenthesizedExpression()
 	}
 
+	if p.token == lexer.NIL {
+		node := NilNode{BaseNode: p.nodeHere()}
+		p.next()
+
+		return node
+	}
+
 	if p.token == lexer.OBRACK {
 		return p.parseSliceOrArray()
 	}
@@ -586,15 +772,40 @@ func (p *Parser) parseCall(callee ValueNode) ValueNode {
 		return node
 	}
 
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
 	for {
 		node.Arguments = append(node.Arguments, p.parseExpression())
 
+		if p.token == lexer.ELLIPSIS {
+			node.Spread = true
+			p.next()
+
+			if p.token == lexer.COMMA {
+				p.next()
+			}
+
+			if p.token != lexer.CPAREN {
+				panic(p.errf(p.pos, "can only use ... with final argument; received '%s'", p.currentTokenString()))
+			}
+		}
+
 		switch p.token {
 		case lexer.CPAREN:
 			node.end = p.pos
 			p.next()
 			return node
 		case lexer.COMMA:
+			p.next()
+
+			// trailing comma, ie `a(\n\tb,\n)`
+			if p.token == lexer.CPAREN {
+				node.end = p.pos
+				p.next()
+				return node
+			}
+
 			continue
 		default:
 			panic(p.errf(p.pos, "unexpected token: '%s'", p.currentTokenString()))
@@ -612,45 +823,127 @@ func (p *Parser) parsePrimaryExpression() ValueNode {
 		case lexer.PERIOD:
 			p.next()
 
+			if p.token == lexer.OPAREN {
+				node = p.parseTypeAssertion(node)
+				continue
+			}
+
 			if p.token != lexer.IDENTIFIER {
 				panic(p.errf(p.pos, "unexpected token: '%s'", p.currentTokenString()))
 			}
 
 			node = PropertyAccessNode{
-				BaseNode:   p.nodeHere(),
+				BaseNode:   p.nodeAt(node.Start()),
 				PropertyOf: node,
 				Property:   p.parseIdentifier(),
 			}
-		case lexer.OBRACK:
-			start := p.pos
-			p.next()
-
-			//TODO: handle semicolons - ie [1:2]
-			key := p.parseExpression()
-
-			if p.token != lexer.CBRACK {
-				panic(p.errf(p.pos, "expecteed closing bracket; received '%s'", p.currentTokenString()))
+		case lexer.OBRACE:
+			if !isTypeName(node) || p.exprLev < 0 {
+				return node
 			}
 
-			end := p.pos
-			p.next()
-
-			return IndexNode{
-				BaseNode: p.nodeAt(start),
-				IndexOf:  node,
-				Key:      key,
-				end:      end,
+			node = CompositeValueNode{
+				BaseNode: p.nodeAt(node.Start()),
+				Type:     node.(TypeNode),
+				Elements: p.parseKeyedElements(true),
 			}
+		case lexer.OBRACK:
+			node = p.parseIndexOrSlice(node)
 		default:
 			return node
 		}
 	}
 }
 
+// Parses the `[i]` of `a[i]`, or the `[low:high]` of `a[low:high]` (where
+// either bound can be omitted).
+func (p *Parser) parseIndexOrSlice(value ValueNode) ValueNode {
+	p.next()
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
+	var low ValueNode
+
+	if p.token != lexer.COLON {
+		low = p.parseExpression()
+	}
+
+	if p.token == lexer.CBRACK && low != nil {
+		node := IndexNode{
+			BaseNode: p.nodeAt(value.Start()),
+			IndexOf:  value,
+			Key:      low,
+			end:      p.pos,
+		}
+		p.next()
+
+		return node
+	}
+
+	if p.token != lexer.COLON {
+		panic(p.errf(p.pos, "expected closing bracket; received '%s'", p.currentTokenString()))
+	}
+
+	p.next()
+
+	node := SliceNode{
+		BaseNode: p.nodeAt(value.Start()),
+		SliceOf:  value,
+		Low:      low,
+	}
+
+	if p.token != lexer.CBRACK {
+		node.High = p.parseExpression()
+	}
+
+	if p.token != lexer.CBRACK {
+		panic(p.errf(p.pos, "expected closing bracket; received '%s'", p.currentTokenString()))
+	}
+
+	node.end = p.pos
+	p.next()
+
+	return node
+}
+
+// Parses the `(T)` of `a.(T)`.
+func (p *Parser) parseTypeAssertion(value ValueNode) TypeAssertionNode {
+	node := TypeAssertionNode{
+		BaseNode: p.nodeAt(value.Start()),
+		Value:    value,
+	}
+	p.next()
+
+	node.Type = p.parseType()
+
+	if p.token != lexer.CPAREN {
+		panic(p.errf(p.pos, "expected ')'; received '%s'", p.currentTokenString()))
+	}
+
+	node.end = p.pos + 1
+	p.next()
+
+	return node
+}
+
 func (p *Parser) parseExpression() ValueNode {
 	return p.parseBinaryExpr(0)
 }
 
+// Whether node can be the type of a composite literal, ie `T` in `T{a: 1}`
+func isTypeName(node ValueNode) bool {
+	switch node := node.(type) {
+	case IdentifierNode:
+		return true
+	case PropertyAccessNode:
+		// A name qualified by a module, ie `factorio.Lamp{}`.
+		_, ok := node.PropertyOf.(IdentifierNode)
+		return ok
+	}
+
+	return false
+}
+
 // func (p *Parser) parseKeyword() StepNode {
 // 	switch p.token {
 // 	case lexer.FUNC:
@@ -673,10 +966,12 @@ func (p *Parser) parseVariableDeclaration() DeclarationNode {
 		name:     p.raw,
 	}
 
-	p.next()
+	if name := p.parseIdentifier(); p.token == lexer.COMMA {
+		return p.parseTupleDeclaration(start, name)
+	}
 
-	if p.token == lexer.IDENTIFIER {
-		v.Type = p.parseIdentifier()
+	if p.isTypeStart() {
+		v.Type = p.parseType()
 	}
 
 	if p.token != lexer.ASSIGN {
@@ -694,6 +989,48 @@ func (p *Parser) parseVariableDeclaration() DeclarationNode {
 	return v
 }
 
+// Parses the rest of `var a, b int = 1, 2` after `var a`.
+func (p *Parser) parseTupleDeclaration(start token.Pos, first IdentifierNode) TupleDeclarationNode {
+	t := TupleDeclarationNode{
+		BaseNode: p.nodeAt(start),
+		Names:    []IdentifierNode{first},
+	}
+
+	for p.token == lexer.COMMA {
+		p.next()
+		t.Names = append(t.Names, p.parseIdentifier())
+	}
+
+	if p.token == lexer.IDENTIFIER {
+		t.Type = p.parseTypeName()
+	}
+
+	if p.token != lexer.ASSIGN {
+		if t.Type != nil && p.didTerminate() {
+			return t
+		}
+
+		panic(p.err(start, "expected variable declaration to contain type or initial value"))
+	}
+
+	p.next()
+	t.Values = p.parseExpressionList()
+
+	return t
+}
+
+// Parses comma-separated expressions, ie `a, b + 1`.
+func (p *Parser) parseExpressionList() []ValueNode {
+	list := []ValueNode{p.parseExpression()}
+
+	for p.token == lexer.COMMA {
+		p.next()
+		list = append(list, p.parseExpression())
+	}
+
+	return list
+}
+
 func (p *Parser) parseConstantDeclaration() DeclarationNode {
 	start := p.pos
 	p.next()
@@ -710,8 +1047,7 @@ func (p *Parser) parseConstantDeclaration() DeclarationNode {
 	p.next()
 
 	if p.token == lexer.IDENTIFIER {
-		c.Type = p.parseIdentifier()
-		p.next()
+		c.Type = p.parseTypeName()
 	}
 
 	if p.token != lexer.ASSIGN {
@@ -724,7 +1060,7 @@ func (p *Parser) parseConstantDeclaration() DeclarationNode {
 	return c
 }
 
-func (p *Parser) tryParseAssignment(target IdentifierNode) StepNode {
+func (p *Parser) tryParseAssignment(target ValueNode) StepNode {
 	if p.token.IsAssignmentOperator() {
 		value := BinaryOperationNode{
 			BaseNode: p.nodeHere(),
@@ -735,11 +1071,11 @@ func (p *Parser) tryParseAssignment(target IdentifierNode) StepNode {
 
 		value.Right = p.parseExpression()
 
-		return p.assertTerminator(AssignmentNode{
+		return AssignmentNode{
 			BaseNode: p.nodeAt(target.Start()),
 			Assignee: target,
 			Value:    value,
-		})
+		}
 	}
 
 	if p.token == lexer.ASSIGN {
@@ -752,68 +1088,104 @@ func (p *Parser) tryParseAssignment(target IdentifierNode) StepNode {
 		node.Value = p.parseExpression()
 		node.end = node.Value.End()
 
-		return p.assertTerminator(node)
+		return node
 	}
 
 	if p.token == lexer.DEFINE {
+		ident, ok := target.(IdentifierNode)
+
+		if !ok {
+			panic(p.err(target.Start(), "expected a name on the left side of ':='"))
+		}
+
 		p.next()
 
-		return p.assertTerminator(VariableDeclarationNode{
+		return VariableDeclarationNode{
 			BaseNode: p.nodeAt(target.Start()),
-			name:     target.Target,
+			name:     ident.Target,
 			Value:    p.parseExpression(),
-		})
+		}
 	}
 
 	return nil
 }
 
 func (p *Parser) parseStep() StepNode {
-	var (
-		target      ValueNode
-		didIndirect bool
-	)
-
 	switch p.token {
 	case lexer.VAR:
 		return p.parseVariableDeclaration()
 	case lexer.IDENTIFIER:
-		target = p.parsePrimaryExpression()
+		return p.parseSimpleStep(p.parseExpression())
 	case lexer.RETURN:
 		node := ReturnNode{BaseNode: p.nodeHere()}
 		p.next()
-		node.Value = p.parseExpression()
-		return node
+
+		if !p.didTerminate() {
+			node.Values = p.parseExpressionList()
+		}
+
+		return node
+	case lexer.THROW:
+		node := ThrowNode{BaseNode: p.nodeHere()}
+		p.next()
+		node.Value = p.parseExpression()
+
+		return node
+	case lexer.CATCH:
+		return p.parseTupleAssignment(p.pos, nil)
+	case lexer.START:
+		node := StartNode{BaseNode: p.nodeHere()}
+		p.next()
+
+		call, ok := p.parseExpression().(CallNode)
+
+		if !ok {
+			panic(p.err(node.start, "expression in start must be function call"))
+		}
+
+		node.Call = call
+
+		return node
 	case lexer.IF:
 		return p.parseIf()
+	case lexer.FOR:
+		return p.parseFor()
+	case lexer.SWITCH:
+		return p.parseSwitch()
+	case lexer.BREAK, lexer.CONTINUE, lexer.FALLTHROUGH:
+		node := BranchNode{BaseNode: p.nodeHere(), Token: p.token}
+		p.next()
+
+		return node
 	default:
 		panic(p.errf(p.pos, "unexpected token: %s", p.currentTokenString()))
 	}
+}
 
+// Parses the rest of a statement which starts with target, ie an assignment or
+// a call.  Also used for the init and post statements of `for` loops.
+func (p *Parser) parseSimpleStep(target ValueNode) StepNode {
 	switch target := target.(type) {
 	case CallNode:
-		if !didIndirect {
-			return target
-		}
-	case BinaryOperationNode, ArrayValueNode, SliceValueNode,
-		StringNode, IntegerNode, FloatNode:
+		return target
+	case SuffixUnaryOperationNode:
+		return target
+	case BinaryOperationNode, UnaryOperationNode, ArrayValueNode, SliceValueNode,
+		SliceNode, StringNode, IntegerNode, FloatNode, CharNode:
 		panic(p.errf(target.Start(), "nothing to do"))
 	}
 
-	if p.token == lexer.INCR || p.token == lexer.DECR {
-		node := SuffixUnaryOperationNode{
-			UnaryOperationNode: UnaryOperationNode{
-				BaseNode: p.nodeHere(),
-				Operand:  target,
-				Operator: p.token,
-			},
-			end: p.pos + 2,
-		}
-		p.next()
-		return p.assertTerminator(node)
+	switch target.(type) {
+	case IdentifierNode, PropertyAccessNode, IndexNode:
+	default:
+		panic(p.err(target.Start(), "unable to assign to a non-identifier"))
+	}
+
+	if p.token == lexer.COMMA {
+		return p.parseTupleAssignment(target.Start(), []ValueNode{target})
 	}
 
-	if node := p.tryParseAssignment(target.(IdentifierNode)); node != nil {
+	if node := p.tryParseAssignment(target); node != nil {
 		return node
 	}
 
@@ -824,25 +1196,269 @@ func (p *Parser) parseStep() StepNode {
 	}
 }
 
+// Parses the rest of `a, b = b, a` or `a, b := f()` after `a`, or all of it
+// if it starts with `catch`.
+func (p *Parser) parseTupleAssignment(start token.Pos, targets []ValueNode) StepNode {
+	var catch ValueNode
+
+	for len(targets) == 0 || p.token == lexer.COMMA {
+		if len(targets) > 0 {
+			p.next()
+		}
+
+		if p.token == lexer.CATCH {
+			p.next()
+			catch = p.parseAssignee()
+			break
+		}
+
+		targets = append(targets, p.parseAssignee())
+	}
+
+	switch p.token {
+	case lexer.COMMA:
+		panic(p.err(p.pos, "catch must be the last target of an assignment"))
+	case lexer.ASSIGN:
+		p.next()
+
+		return TupleAssignmentNode{
+			BaseNode:  p.nodeAt(start),
+			Assignees: targets,
+			Values:    p.parseExpressionList(),
+			Catch:     catch,
+		}
+	case lexer.DEFINE:
+		node := TupleDeclarationNode{
+			BaseNode: p.nodeAt(start),
+			Names:    make([]IdentifierNode, len(targets)),
+			Define:   true,
+		}
+
+		for i, target := range append(targets, catch) {
+			if target == nil {
+				break
+			}
+
+			ident, ok := target.(IdentifierNode)
+
+			if !ok {
+				panic(p.err(target.Start(), "expected a name on the left side of ':='"))
+			}
+
+			if i < len(targets) {
+				node.Names[i] = ident
+			} else {
+				node.Catch = &ident
+			}
+		}
+
+		p.next()
+		node.Values = p.parseExpressionList()
+
+		return node
+	}
+
+	if p.token.IsAssignmentOperator() {
+		panic(p.errf(p.pos, "assignment operation %s requires single-valued expressions", p.currentTokenString()))
+	}
+
+	panic(p.errf(p.pos, "expected '=' or ':='; received '%s'", p.currentTokenString()))
+}
+
+// Parses the target of an assignment.
+func (p *Parser) parseAssignee() ValueNode {
+	target := p.parseExpression()
+
+	switch target.(type) {
+	case IdentifierNode, PropertyAccessNode, IndexNode:
+	default:
+		panic(p.err(target.Start(), "unable to assign to a non-identifier"))
+	}
+
+	return target
+}
+
+// Parses a step, replacing it with an InvalidNode if it contains a syntax error.
+func (p *Parser) tryParseStep() (node StepNode) {
+	start, exprLev, braces := p.pos, p.exprLev, p.braces
+
+	defer func() {
+		if r := recover(); r != nil {
+			p.exprLev = exprLev
+			node = p.handleError(r, start, func() { p.syncStep(p.braces - braces) })
+		}
+	}()
+
+	return p.parseStep()
+}
+
 func (p *Parser) parseBlock() BlockNode {
 	if p.token != lexer.OBRACE {
-		panic(fmt.Errorf("expected steps to start with brace at %s", p.positonString()))
+		panic(p.errf(p.pos, "expected start of block; received '%s'", p.currentTokenString()))
 	}
 
 	node := BlockNode{BaseNode: p.nodeHere()}
 	p.next()
 
+	prev := p.exprLev
+	p.exprLev = 0
+	defer func() { p.exprLev = prev }()
+
 	for p.token != lexer.CBRACE {
-		node.Steps = append(node.Steps, p.parseStep())
-		if p.token == lexer.SEMICOLON {
+		switch p.token {
+		case lexer.EOF:
+			panic(p.err(node.start, "block not terminated; expected '}'"))
+		case lexer.SEMICOLON:
+			p.next()
+			continue
+		}
+
+		step := p.tryParseStep()
+		node.Steps = append(node.Steps, step)
+
+		// The parser has already skipped to the next statement.
+		if _, ok := step.(InvalidNode); ok {
+			continue
+		}
+
+		switch p.token {
+		case lexer.SEMICOLON:
+			p.next()
+		case lexer.CBRACE:
+		default:
+			p.report(p.errf(p.pos, "expected ';' or newline after statement; received '%s'", p.currentTokenString()))
+			p.syncStep(0)
+		}
+	}
+
+	node.end = p.pos + 1
+
+	p.next()
+
+	return node
+}
+
+// Parses `switch [init;] [tag] { cases }`.
+func (p *Parser) parseSwitch() SwitchNode {
+	node := SwitchNode{BaseNode: p.nodeHere()}
+	p.next()
+
+	prev := p.exprLev
+	p.exprLev = -1
+
+	if p.token != lexer.OBRACE {
+		var first ValueNode
+		if p.token != lexer.SEMICOLON {
+			first = p.parseExpression()
+		}
+
+		if p.token == lexer.OBRACE {
+			node.Tag = first
+		} else {
+			if first != nil {
+				node.Init = p.parseSimpleStep(first)
+			}
+
+			if p.token != lexer.SEMICOLON || p.raw == "\n" {
+				panic(p.errf(p.pos, "expected ';' after the switch's init statement; received '%s'", p.currentTokenString()))
+			}
 			p.next()
+
+			if p.token != lexer.OBRACE {
+				node.Tag = p.parseExpression()
+			}
 		}
 	}
 
+	p.exprLev = prev
+
+	if p.token != lexer.OBRACE {
+		panic(p.errf(p.pos, "expected start of switch body; received '%s'", p.currentTokenString()))
+	}
+	p.next()
+
+	hasDefault := false
+
+	for p.token != lexer.CBRACE {
+		switch p.token {
+		case lexer.EOF:
+			panic(p.err(node.start, "switch not terminated; expected '}'"))
+		case lexer.SEMICOLON:
+			p.next()
+			continue
+		case lexer.CASE, lexer.DEFAULT:
+		default:
+			panic(p.errf(p.pos, "expected 'case' or 'default'; received '%s'", p.currentTokenString()))
+		}
+
+		c := p.parseCase()
+
+		if c.Values == nil {
+			if hasDefault {
+				p.report(p.err(c.start, "multiple defaults in switch"))
+			}
+
+			hasDefault = true
+		}
+
+		node.Cases = append(node.Cases, c)
+	}
+
 	node.end = p.pos + 1
+	p.next()
+
+	return node
+}
 
+// Parses `case values:` or `default:`, along with the statements up to the next
+// case or the end of the switch.
+func (p *Parser) parseCase() CaseNode {
+	node := CaseNode{BaseNode: p.nodeHere()}
+
+	prev := p.exprLev
+	p.exprLev = 0
+	defer func() { p.exprLev = prev }()
+
+	if p.token == lexer.CASE {
+		p.next()
+		node.Values = p.parseExpressionList()
+	} else {
+		p.next()
+	}
+
+	if p.token != lexer.COLON {
+		panic(p.errf(p.pos, "expected ':' after case; received '%s'", p.currentTokenString()))
+	}
+
+	node.Body = BlockNode{BaseNode: p.nodeHere()}
 	p.next()
 
+	for p.token != lexer.CASE && p.token != lexer.DEFAULT && p.token != lexer.CBRACE && p.token != lexer.EOF {
+		if p.token == lexer.SEMICOLON {
+			p.next()
+			continue
+		}
+
+		step := p.tryParseStep()
+		node.Body.Steps = append(node.Body.Steps, step)
+
+		// The parser has already skipped to the next statement.
+		if _, ok := step.(InvalidNode); ok {
+			continue
+		}
+
+		switch p.token {
+		case lexer.SEMICOLON:
+			p.next()
+		case lexer.CASE, lexer.DEFAULT, lexer.CBRACE:
+		default:
+			p.report(p.errf(p.pos, "expected ';' or newline after statement; received '%s'", p.currentTokenString()))
+			p.syncStep(0)
+		}
+	}
+
+	node.Body.end = p.pos
+
 	return node
 }
 
@@ -858,6 +1474,7 @@ func (p *Parser) parseFunctionArguments() ArgumentDeclarationsNode {
 	p.next()
 
 	if p.token == lexer.CPAREN {
+		args.end = p.pos + 1
 		p.next()
 		return args
 	}
@@ -874,27 +1491,37 @@ func (p *Parser) parseFunctionArguments() ArgumentDeclarationsNode {
 
 		p.next()
 
+		// Arguments without a type have the type of the next argument, ie
+		// `a, b int`.
 		if p.token == lexer.COMMA {
 			p.next()
 			args.Arguments = append(args.Arguments, arg)
 			continue
 		}
 
-		// TODO: parse type.
+		if p.token == lexer.ELLIPSIS {
+			arg.Variadic = true
+			p.next()
+		}
 
-		if p.token != lexer.IDENTIFIER {
-			panic(p.err(p.pos, "expected identifier"))
+		if !p.isTypeStart() {
+			panic(p.errf(p.pos, "expected type of argument '%s'; received '%s'", arg.name, p.currentTokenString()))
 		}
 
-		arg.Type = p.parseIdentifier()
+		arg.Type = p.parseType()
 
 		args.Arguments = append(args.Arguments, arg)
 
 		switch p.token {
 		case lexer.CPAREN:
+			args.end = p.pos + 1
 			p.next()
 			return args
 		case lexer.COMMA:
+			if arg.Variadic {
+				panic(p.err(arg.Start(), "can only use ... with final argument"))
+			}
+
 			p.next()
 			continue
 		default:
@@ -903,6 +1530,87 @@ func (p *Parser) parseFunctionArguments() ArgumentDeclarationsNode {
 	}
 }
 
+// Whether the current token can start a type.
+func (p *Parser) isTypeStart() bool {
+	return p.token == lexer.IDENTIFIER || p.token == lexer.OBRACK
+}
+
+// Parses the results of a function, which are either a single type or a list
+// in parentheses.  Same as go, either all of the results in a list are named
+// (ie `(a, b int)`) or none of them are (ie `(int, string)`).
+func (p *Parser) parseResults() []ArgumentDeclarationNode {
+	if p.isTypeStart() {
+		typ := p.parseType()
+		return []ArgumentDeclarationNode{{BaseNode: p.nodeAt(typ.Start()), Type: typ}}
+	}
+
+	if p.token != lexer.OPAREN {
+		return nil
+	}
+
+	p.next()
+
+	var (
+		results []ArgumentDeclarationNode
+		named   bool
+	)
+
+	for p.token != lexer.CPAREN {
+		if !p.isTypeStart() {
+			panic(p.errf(p.pos, "expected result type; received '%s'", p.currentTokenString()))
+		}
+
+		typ := p.parseType()
+		result := ArgumentDeclarationNode{BaseNode: p.nodeAt(typ.Start()), Type: typ}
+
+		// The type was actually the result's name.
+		if p.isTypeStart() {
+			ident, ok := typ.(IdentifierNode)
+
+			if !ok {
+				panic(p.err(typ.Start(), "expected result name"))
+			}
+
+			result.name, result.Type, named = ident.Target, p.parseType(), true
+		}
+
+		results = append(results, result)
+
+		if p.token == lexer.COMMA {
+			p.next()
+		} else if p.token != lexer.CPAREN {
+			panic(p.errf(p.pos, "expected comma or closing parenthesis; received '%s'", p.currentTokenString()))
+		}
+	}
+
+	p.next()
+
+	if len(results) == 0 {
+		panic(p.err(p.pos, "expected at least one result"))
+	}
+
+	if !named {
+		return results
+	}
+
+	// The results without a name are names without a type.
+	for i, result := range results {
+		if result.name != "" {
+			continue
+		}
+
+		ident, ok := result.Type.(IdentifierNode)
+
+		if !ok {
+			panic(p.err(result.Start(), "mixed named and unnamed results"))
+		}
+
+		results[i].name, results[i].Type = ident.Target, nil
+	}
+
+	return results
+}
+
 func (p *Parser) parseFunctionArgumentsAndBlock(start token.Pos) FunctionNode {
 	node := FunctionNode{
 		BaseNode: p.nodeAt(start),
@@ -914,9 +1622,7 @@ func (p *Parser) parseFunctionArgumentsAndBlock(start token.Pos) FunctionNode {
 		panic(p.errf(p.pos, "expected start of function arguments; received '%s'", p.currentTokenString()))
 	}
 
-	if p.token == lexer.IDENTIFIER {
-		node.Returns = p.parseIdentifier()
-	}
+	node.Results = p.parseResults()
 
 	if p.token == lexer.OBRACE {
 		node.Block = p.parseBlock()
@@ -936,7 +1642,7 @@ func (p *Parser) parseFunctionHead() TopLevelNode {
 	)
 
 	if p.token != lexer.FUNC {
-		panic(fmt.Errorf("invalid usage of parseTopLevelFunc at %s", p.positonString()))
+		panic(p.errf(p.pos, "expected 'func'; received '%s'", p.currentTokenString()))
 	}
 	p.next()
 
@@ -999,47 +1705,321 @@ func (p *Parser) parseTopLevelFunc() TopLevelNode {
 	return fn
 }
 
-func (p *Parser) ParseModule() (mod ModuleNode) {
+// Parses `struct name { fields }`.  Fields are separated by newlines, ie:
+//
+//	struct T {
+//		Embedded
+//		a, b int
+//	}
+func (p *Parser) parseStructDeclaration() StructDeclarationNode {
+	node := StructDeclarationNode{BaseNode: p.nodeHere()}
+	p.next()
 
-	for p.token != lexer.EOF {
-		var (
-			node     TopLevelNode
-			isPublic bool
-			start    = p.pos
-		)
+	if p.token != lexer.IDENTIFIER {
+		panic(p.errf(p.pos, "expected struct name; received '%s'", p.currentTokenString()))
+	}
+
+	node.name = p.raw
+	p.next()
+
+	if p.token != lexer.OBRACE {
+		panic(p.errf(p.pos, "expected '{'; received '%s'", p.currentTokenString()))
+	}
+	p.next()
 
-		if isPublic = p.token == lexer.PUBLIC; isPublic {
+	for p.token != lexer.CBRACE {
+		if p.token == lexer.SEMICOLON {
 			p.next()
-			if p.token == lexer.PUBLIC {
-				panic(p.err(p.pos, "unexpected token"))
-			}
+			continue
 		}
 
+		node.Fields = append(node.Fields, p.parseFieldDeclarations()...)
+
 		switch p.token {
-		case lexer.FUNC:
-			node = p.parseTopLevelFunc()
-		case lexer.VAR:
-			node = p.parseVariableDeclaration()
-		case lexer.CONST:
-			node = p.parseConstantDeclaration()
 		case lexer.SEMICOLON:
 			p.next()
+		case lexer.CBRACE:
+		default:
+			panic(p.errf(p.pos, "expected ';' or newline after field; received '%s'", p.currentTokenString()))
+		}
+	}
+
+	node.end = p.pos + 1
+	p.next()
+
+	return node
+}
+
+// Parses a line of a struct's fields: either `a, b T` or an embedded `T`.
+func (p *Parser) parseFieldDeclarations() []FieldDeclarationNode {
+	if p.token != lexer.IDENTIFIER {
+		panic(p.errf(p.pos, "expected field name; received '%s'", p.currentTokenString()))
+	}
+
+	names := []IdentifierNode{p.parseIdentifier()}
+
+	// Embedded field.
+	if p.token == lexer.SEMICOLON || p.token == lexer.CBRACE {
+		return []FieldDeclarationNode{{
+			BaseNode: names[0].BaseNode,
+			Type:     names[0],
+		}}
+	}
+
+	for p.token == lexer.COMMA {
+		p.next()
+
+		if p.token != lexer.IDENTIFIER {
+			panic(p.errf(p.pos, "expected field name; received '%s'", p.currentTokenString()))
+		}
+
+		names = append(names, p.parseIdentifier())
+	}
+
+	typ := p.parseType()
+	fields := make([]FieldDeclarationNode, len(names))
+
+	for i, name := range names {
+		fields[i] = FieldDeclarationNode{
+			BaseNode: name.BaseNode,
+			name:     name.Target,
+			Type:     typ,
+		}
+	}
+
+	return fields
+}
+
+// Parses `enum name { members }`.  Members are separated by newlines, ie:
+//
+//	enum Color {
+//		Red
+//		Green
+//	}
+func (p *Parser) parseEnumDeclaration() EnumDeclarationNode {
+	node := EnumDeclarationNode{BaseNode: p.nodeHere()}
+	p.next()
+
+	if p.token != lexer.IDENTIFIER {
+		panic(p.errf(p.pos, "expected enum name; received '%s'", p.currentTokenString()))
+	}
+
+	node.name = p.raw
+	p.next()
+
+	if p.token != lexer.OBRACE {
+		panic(p.errf(p.pos, "expected '{'; received '%s'", p.currentTokenString()))
+	}
+	p.next()
+
+	for p.token != lexer.CBRACE {
+		if p.token == lexer.SEMICOLON {
+			p.next()
 			continue
+		}
+
+		if p.token != lexer.IDENTIFIER {
+			panic(p.errf(p.pos, "expected enum member; received '%s'", p.currentTokenString()))
+		}
+
+		node.Members = append(node.Members, p.parseIdentifier())
+
+		switch p.token {
+		case lexer.SEMICOLON:
+			p.next()
+		case lexer.CBRACE:
 		default:
-			panic(p.errf(p.pos, "unexpected identifier %s", inspector.Inspect(p.token)))
+			panic(p.errf(p.pos, "expected ';' or newline after enum member; received '%s'", p.currentTokenString()))
 		}
+	}
 
-		if isPublic {
-			node = PublicNode{
-				BaseNode: p.nodeAt(start),
-				Node:     node,
+	node.end = p.pos + 1
+	p.next()
+
+	return node
+}
+
+// Parses `interface name { methods }`.  Methods are separated by newlines, and
+// a lone name embeds another interface, ie:
+//
+//	interface Shape {
+//		Named
+//		area() int
+//		scale(by int) Shape
+//	}
+func (p *Parser) parseInterfaceDeclaration() InterfaceDeclarationNode {
+	node := InterfaceDeclarationNode{BaseNode: p.nodeHere()}
+	p.next()
+
+	if p.token != lexer.IDENTIFIER {
+		panic(p.errf(p.pos, "expected interface name; received '%s'", p.currentTokenString()))
+	}
+
+	node.name = p.raw
+	p.next()
+
+	if p.token != lexer.OBRACE {
+		panic(p.errf(p.pos, "expected '{'; received '%s'", p.currentTokenString()))
+	}
+	p.next()
+
+	for p.token != lexer.CBRACE {
+		if p.token == lexer.SEMICOLON {
+			p.next()
+			continue
+		}
+
+		if p.token != lexer.IDENTIFIER {
+			panic(p.errf(p.pos, "expected method name; received '%s'", p.currentTokenString()))
+		}
+
+		name := p.parseIdentifier()
+
+		switch p.token {
+		case lexer.SEMICOLON, lexer.CBRACE:
+			node.Embedded = append(node.Embedded, name)
+		case lexer.OPAREN:
+			method := InterfaceMethodNode{BaseNode: name.BaseNode, name: name.Target}
+			method.Arguments = p.parseFunctionArguments()
+			method.end = method.Arguments.End()
+
+			if method.Results = p.parseResults(); len(method.Results) > 0 {
+				method.end = method.Results[len(method.Results)-1].End()
 			}
+
+			node.Methods = append(node.Methods, method)
+		default:
+			panic(p.errf(p.pos, "expected '(' after method name; received '%s'", p.currentTokenString()))
+		}
+
+		switch p.token {
+		case lexer.SEMICOLON:
+			p.next()
+		case lexer.CBRACE:
+		default:
+			panic(p.errf(p.pos, "expected ';' or newline after method; received '%s'", p.currentTokenString()))
+		}
+	}
+
+	node.end = p.pos + 1
+	p.next()
+
+	return node
+}
+
+// Parses a top-level declaration, replacing it with an InvalidNode if it
+// contains a syntax error.
+func (p *Parser) parseTopLevel() (node TopLevelNode) {
+	var (
+		isPublic bool
+		start    = p.pos
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			p.exprLev = 0
+			node = p.handleError(r, start, p.syncTopLevel)
+		}
+	}()
+
+	if isPublic = p.token == lexer.PUBLIC; isPublic {
+		p.next()
+		if p.token == lexer.PUBLIC {
+			panic(p.err(p.pos, "unexpected token"))
+		}
+	}
+
+	switch p.token {
+	case lexer.IMPORT:
+		if isPublic {
+			panic(p.err(start, "imports can't be public"))
+		}
+
+		node = p.parseImport()
+	case lexer.FUNC:
+		node = p.parseTopLevelFunc()
+	case lexer.VAR:
+		node = p.parseVariableDeclaration()
+	case lexer.CONST:
+		node = p.parseConstantDeclaration()
+	case lexer.STRUCT:
+		node = p.parseStructDeclaration()
+	case lexer.ENUM:
+		node = p.parseEnumDeclaration()
+	case lexer.INTERFACE:
+		node = p.parseInterfaceDeclaration()
+	default:
+		panic(p.errf(p.pos, "unexpected token: '%s'", p.currentTokenString()))
+	}
+
+	if isPublic {
+		node = PublicNode{
+			BaseNode: p.nodeAt(start),
+			Node:     node,
+		}
+	}
+
+	return node
+}
+
+// Parses `import "path"` or `import alias "path"`.
+func (p *Parser) parseImport() ImportDeclarationNode {
+	node := ImportDeclarationNode{BaseNode: p.nodeHere()}
+	p.next()
+
+	if p.token == lexer.IDENTIFIER {
+		node.Alias = p.raw
+		p.next()
+	}
+
+	if p.token != lexer.STRING {
+		panic(p.errf(p.pos, "expected import path; received '%s'", p.currentTokenString()))
+	}
+
+	path := p.parseString().(StringNode)
+
+	if path.Value == "" {
+		panic(p.err(path.Start(), "invalid import path: empty"))
+	}
+
+	node.Path, node.end = path.Value, path.End()
+
+	return node
+}
+
+// Parses the module.  Syntax errors don't stop the parser; they're collected
+// (along with any errors from the scanner) and the declaration or statement
+// containing them is replaced with an InvalidNode.
+func (p *Parser) ParseModule() (mod ModuleNode, errs []lexer.PositionError) {
+	declared := false
+
+	for p.token != lexer.EOF {
+		if p.token == lexer.SEMICOLON {
+			p.next()
+			continue
 		}
 
+		node := p.parseTopLevel()
 		mod.Nodes = append(mod.Nodes, node)
+
+		// Same as go, imports come first.
+		if _, ok := node.(ImportDeclarationNode); !ok {
+			declared = true
+		} else if declared {
+			p.report(p.err(node.Start(), "imports must appear before other declarations"))
+		}
+
+		if _, ok := node.(InvalidNode); ok {
+			continue
+		}
+
+		if p.token != lexer.SEMICOLON && p.token != lexer.EOF {
+			p.report(p.errf(p.pos, "expected ';' or newline after declaration; received '%s'", p.currentTokenString()))
+			p.syncTopLevel()
+		}
 	}
 
-	return
+	return mod, p.diagnostics()
 }
 
 func (p *Parser) parseIfOnly() IfNode {
@@ -1049,7 +2029,32 @@ func (p *Parser) parseIfOnly() IfNode {
 
 	p.next()
 
-	node.Condition = p.parseExpression()
+	prev := p.exprLev
+	p.exprLev = -1
+
+	var first ValueNode
+	if p.token != lexer.SEMICOLON {
+		first = p.parseExpression()
+	}
+
+	switch {
+	case p.token == lexer.SEMICOLON && p.raw != "\n", p.token == lexer.DEFINE, p.token == lexer.ASSIGN, p.token == lexer.COMMA,
+		p.token.IsAssignmentOperator():
+		if first != nil {
+			node.Init = p.parseSimpleStep(first)
+		}
+
+		if p.token != lexer.SEMICOLON || p.raw == "\n" {
+			panic(p.errf(p.pos, "expected ';' after the if's init statement; received '%s'", p.currentTokenString()))
+		}
+		p.next()
+
+		node.Condition = p.parseExpression()
+	default:
+		node.Condition = first
+	}
+
+	p.exprLev = prev
 
 	if p.token != lexer.OBRACE {
 		panic(p.err(p.pos, "expected start of block"))
@@ -1060,7 +2065,8 @@ func (p *Parser) parseIfOnly() IfNode {
 	return node
 }
 
-// TODO: go's initialization in `if` statements is super handy,
+// Parses `if [init;] condition { block }`, followed by any number of `else if`s
+// (which can have init statements as well) and an optional `else`.
 func (p *Parser) parseIf() IfNode {
 	node := p.parseIfOnly()
 
@@ -1079,3 +2085,61 @@ func (p *Parser) parseIf() IfNode {
 
 	return node
 }
+
+// Parses `for [init;] [condition] [; post] { block }`.
+func (p *Parser) parseFor() ForNode {
+	node := ForNode{BaseNode: p.nodeHere()}
+	p.next()
+
+	if p.token == lexer.OBRACE {
+		node.Block = p.parseBlock()
+		return node
+	}
+
+	prev := p.exprLev
+	p.exprLev = -1
+
+	var first ValueNode
+	if p.token != lexer.SEMICOLON {
+		first = p.parseExpression()
+	}
+
+	// `for condition {`
+	if p.token == lexer.OBRACE {
+		p.exprLev = prev
+		node.Condition = first
+		node.Block = p.parseBlock()
+		return node
+	}
+
+	if first != nil {
+		node.Init = p.parseSimpleStep(first)
+	}
+
+	if p.token != lexer.SEMICOLON || p.raw == "\n" {
+		panic(p.errf(p.pos, "expected ';' after the loop's init statement; received '%s'", p.currentTokenString()))
+	}
+	p.next()
+
+	if p.token != lexer.SEMICOLON {
+		node.Condition = p.parseExpression()
+	}
+
+	if p.token != lexer.SEMICOLON || p.raw == "\n" {
+		panic(p.errf(p.pos, "expected ';' after the loop's condition; received '%s'", p.currentTokenString()))
+	}
+	p.next()
+
+	if p.token != lexer.OBRACE {
+		node.Post = p.parseSimpleStep(p.parseExpression())
+
+		if _, ok := node.Post.(VariableDeclarationNode); ok {
+			panic(p.err(node.Post.Start(), "cannot declare variables in the loop's post statement"))
+		}
+	}
+
+	p.exprLev = prev
+	node.Block = p.parseBlock()
+
+	return node
+}
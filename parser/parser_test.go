@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		src  string
+		// Every error expected, in order, as the start of its message after
+		// the position.
+		errs []string
+		// Whether each top-level declaration parsed, in order.
+		valid []bool
+	}{
+		{
+			name:  "no errors",
+			src:   "var a = 1\n\nfunc main() {\n\ta = 2\n}\n",
+			valid: []bool{true, true},
+		},
+		{
+			name:  "error in each function",
+			src:   "func main() {\n\ta := )\n}\n\nfunc f() {\n\tb := ]\n}\n",
+			errs:  []string{"2:7: unexpected token: ')'", "6:7: unexpected token: ']'"},
+			valid: []bool{true, true},
+		},
+		{
+			name:  "several errors in a block",
+			src:   "func main() {\n\ta := )\n\tb := 1\n\tc := ]\n}\n",
+			errs:  []string{"2:7: unexpected token: ')'", "4:7: unexpected token: ']'"},
+			valid: []bool{true},
+		},
+		{
+			name:  "error in a composite literal",
+			src:   "func main() {\n\ta := T{1, )}\n\tb := ]\n}\n",
+			errs:  []string{"2:12: unexpected token: ')'", "3:7: unexpected token: ']'"},
+			valid: []bool{true},
+		},
+		{
+			name:  "invalid declarations",
+			src:   "var a = \nvar b = 2\nfunc ( {\n}\nvar c = 3\n",
+			errs:  []string{"2:1: unexpected token: 'var'", "3:6: expected identifier; received '('"},
+			valid: []bool{false, true, false, true},
+		},
+		{
+			name:  "scanner error",
+			src:   "func main() {\n\tif {\n\t}\n\tx := \"abc\n}\n",
+			errs:  []string{"2:5: unexpected token: '{'", "4:7: literal not terminated"},
+			valid: []bool{true},
+		},
+		{
+			name:  "if without a condition",
+			src:   "func main() {\n\tif x := 1 {\n\t}\n}\n",
+			errs:  []string{"2:12: expected ';' after the if's init statement; received '{'"},
+			valid: []bool{true},
+		},
+		{
+			name:  "start without a call",
+			src:   "func main() {\n\tstart 1\n}\n",
+			errs:  []string{"2:2: expression in start must be function call"},
+			valid: []bool{true},
+		},
+		{
+			name:  "import after a declaration",
+			src:   "var a = 1\nimport \"x\"\n",
+			errs:  []string{"2:1: imports must appear before other declarations"},
+			valid: []bool{true, true},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			file := token.NewFileSet().AddFile("test.tbd", -1, len(test.src))
+			mod, errs := NewParser([]byte(test.src), file).ParseModule()
+
+			var got []string
+
+			for _, err := range errs {
+				got = append(got, strings.TrimPrefix(err.Error(), "test.tbd:"))
+			}
+
+			if len(got) != len(test.errs) {
+				t.Fatalf("errors %q; want %q", got, test.errs)
+			}
+
+			for i, err := range test.errs {
+				if !strings.HasPrefix(got[i], err) {
+					t.Errorf("errors %q; want %q", got, test.errs)
+				}
+			}
+
+			if len(mod.Nodes) != len(test.valid) {
+				t.Fatalf("%d declarations; want %d", len(mod.Nodes), len(test.valid))
+			}
+
+			for i, node := range mod.Nodes {
+				if _, invalid := node.(InvalidNode); invalid == test.valid[i] {
+					t.Errorf("declaration %d is a %T; want it to be valid: %t", i, node, test.valid[i])
+				}
+			}
+		})
+	}
+}